@@ -0,0 +1,754 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redraskal/r6-dissect/dissect"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+func runInfo(inputs []input) error {
+	for _, in := range inputs {
+		if len(inputs) > 1 {
+			log.Info().Msgf("Input:            %s", in)
+		}
+		f, err := in.open()
+		if err != nil {
+			return err
+		}
+		err = printHead(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runExport(inputs []input) error {
+	if viper.GetBool("info") {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return runInfo(inputs)
+	}
+	if viper.GetBool("dump") {
+		return runDump(inputs)
+	}
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	dir := viper.GetString("output-dir")
+	if len(dir) > 0 && viper.IsSet("output") {
+		return newUsageError("specify either --output or --output-dir")
+	}
+	if format == CSV || format == SQLite || format == Parquet {
+		return exportTables(inputs, format)
+	}
+	if format == Excel && len(inputs) > 1 && len(dir) == 0 {
+		return exportWorkbook(inputs)
+	}
+	var errs []error
+	if len(dir) > 0 {
+		errs, err = exportToDir(inputs, format, dir)
+	} else if format == NDJSON {
+		errs, err = streamInputs(inputs)
+	} else {
+		errs, err = exportToStream(inputs, format)
+	}
+	if err != nil {
+		return err
+	}
+	if len(inputs) > 1 {
+		return exportSummary(inputs, errs)
+	}
+	return errs[0]
+}
+
+// exportToDir writes one output file per input to dir.
+func exportToDir(inputs []input, format OutputFormat, dir string) ([]error, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	paths := exportPaths(inputs, format, dir)
+	errs := make([]error, len(inputs))
+	forEachParallel(len(inputs), viper.GetInt("parallel"), func(i int) {
+		out, err := os.Create(paths[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		errs[i] = exportInput(inputs[i], format, out)
+		if err = out.Close(); errs[i] == nil {
+			errs[i] = err
+		}
+		if errs[i] != nil {
+			os.Remove(paths[i])
+		}
+	})
+	return errs, nil
+}
+
+// streamInputs writes the NDJSON lines of every input to the output
+// one input at a time, as each round is decoded.
+func streamInputs(inputs []input) ([]error, error) {
+	out, err := openOutput()
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	errs := make([]error, len(inputs))
+	for i, in := range inputs {
+		errs[i] = exportInput(in, NDJSON, out)
+	}
+	return errs, nil
+}
+
+// exportToStream writes every input to the output in order,
+// one JSON document per line.
+func exportToStream(inputs []input, format OutputFormat) ([]error, error) {
+	out, err := openOutput()
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	errs := make([]error, len(inputs))
+	bufs := make([]bytes.Buffer, len(inputs))
+	done := make([]chan struct{}, len(inputs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	go forEachParallel(len(inputs), viper.GetInt("parallel"), func(i int) {
+		errs[i] = exportInput(inputs[i], format, &bufs[i])
+		close(done[i])
+	})
+	for i := range inputs {
+		<-done[i]
+		if errs[i] == nil {
+			if _, err := bufs[i].WriteTo(out); err != nil {
+				return nil, err
+			}
+		}
+		bufs[i] = bytes.Buffer{}
+	}
+	return errs, nil
+}
+
+// exportTables writes every input to a single set of tables:
+// a directory of CSV or Parquet files, or a SQLite database.
+func exportTables(inputs []input, format OutputFormat) error {
+	path := viper.GetString("output-dir")
+	if len(path) == 0 {
+		path = viper.GetString("output")
+	}
+	if len(path) == 0 {
+		return newUsageError("%s export requires an output path (-o)", format)
+	}
+	w, err := openTables(format, path)
+	if err != nil {
+		return err
+	}
+	errs := make([]error, len(inputs))
+	readInputsOrdered(inputs, func(i int, m *dissect.MatchReader, r *dissect.Reader, err error) {
+		if err != nil {
+			errs[i] = err
+		} else if m != nil {
+			errs[i] = w.WriteMatch(m)
+		} else {
+			errs[i] = w.WriteRound(r)
+		}
+	})
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if len(inputs) > 1 {
+		return exportSummary(inputs, errs)
+	}
+	return errs[0]
+}
+
+// exportWorkbook combines every match input into one Excel workbook.
+func exportWorkbook(inputs []input) error {
+	layout, err := excelLayout()
+	if err != nil {
+		return err
+	}
+	errs := make([]error, len(inputs))
+	matches := make([]*dissect.MatchReader, 0, len(inputs))
+	readInputsOrdered(inputs, func(i int, m *dissect.MatchReader, r *dissect.Reader, err error) {
+		if err != nil {
+			errs[i] = err
+		} else if m == nil {
+			errs[i] = errors.New("combined workbooks require match folders")
+		} else {
+			matches = append(matches, m)
+		}
+	})
+	if len(matches) > 0 {
+		out, err := openOutput()
+		if err != nil {
+			return err
+		}
+		err = dissect.WriteExcelMatchesLayout(out, matches, layout)
+		if err = errors.Join(err, out.Close()); err != nil {
+			return err
+		}
+	}
+	return exportSummary(inputs, errs)
+}
+
+// readInputsOrdered reads inputs in parallel and calls fn with
+// each result in the order of inputs.
+func readInputsOrdered(inputs []input, fn func(i int, m *dissect.MatchReader, r *dissect.Reader, err error)) {
+	type result struct {
+		m    *dissect.MatchReader
+		r    *dissect.Reader
+		err  error
+		done chan struct{}
+	}
+	results := make([]result, len(inputs))
+	for i := range results {
+		results[i].done = make(chan struct{})
+	}
+	go forEachParallel(len(inputs), viper.GetInt("parallel"), func(i int) {
+		results[i].m, results[i].r, results[i].err = readInput(inputs[i])
+		close(results[i].done)
+	})
+	for i := range results {
+		<-results[i].done
+		fn(i, results[i].m, results[i].r, results[i].err)
+		results[i] = result{}
+	}
+}
+
+// tableWriter is implemented by formats that combine
+// several matches into related tables.
+type tableWriter interface {
+	WriteMatch(m *dissect.MatchReader) error
+	WriteRound(r *dissect.Reader) error
+	Close() error
+}
+
+func openTables(format OutputFormat, path string) (tableWriter, error) {
+	switch format {
+	case SQLite:
+		return openSQLite(path)
+	case Parquet:
+		partition, err := parquetPartition()
+		if err != nil {
+			return nil, err
+		}
+		return dissect.NewParquetWriter(path, partition), nil
+	}
+	return dissect.CreateCSVFiles(path)
+}
+
+func parquetPartition() (dissect.ParquetPartition, error) {
+	switch strings.ToLower(viper.GetString("partition")) {
+	case "", "match":
+		return dissect.PartitionByMatch, nil
+	case "day":
+		return dissect.PartitionByDay, nil
+	}
+	return 0, newUsageError("specify a valid parquet partition (match, day)")
+}
+
+// writeTables writes a match or a round to the tables at path.
+func writeTables(format OutputFormat, path string, m *dissect.MatchReader, r *dissect.Reader) error {
+	w, err := openTables(format, path)
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		err = w.WriteMatch(m)
+	} else {
+		err = w.WriteRound(r)
+	}
+	return errors.Join(err, w.Close())
+}
+
+// exportPaths names output files after their inputs, adding
+// a numbered suffix when inputs share the same name.
+func exportPaths(inputs []input, format OutputFormat, dir string) []string {
+	paths := make([]string, len(inputs))
+	// names are compared case-insensitively for Windows and macOS
+	used := make(map[string]bool)
+	for i, in := range inputs {
+		base := "stdin"
+		if !in.stdin() {
+			base = strings.TrimSuffix(filepath.Base(in.path), ".rec")
+		}
+		name := base
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[strings.ToLower(name)] = true
+		paths[i] = filepath.Join(dir, name+formatExtension(format))
+	}
+	return paths
+}
+
+func forEachParallel(n, parallel int, fn func(i int)) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func exportSummary(inputs []input, errs []error) error {
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d of %d inputs\n", len(inputs)-failed, len(inputs))
+	for i, in := range inputs {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "  failed  %s: %v\n", in, errs[i])
+		} else {
+			fmt.Fprintf(os.Stderr, "  ok      %s\n", in)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed", failed, len(inputs))
+	}
+	return nil
+}
+
+func exportInput(in input, format OutputFormat, out io.Writer) error {
+	f, err := in.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if in.isJSON() {
+		m, r, err := dissect.ReadJSON(f)
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			cliStats.match(m)
+			return writeMatchData(m, format, out)
+		}
+		cliStats.round(r)
+		return writeRoundData(r, format, out)
+	}
+	dir, err := in.isDir()
+	if err != nil {
+		return err
+	}
+	if dir {
+		return writeMatch(f, format, out, cliStats)
+	}
+	return writeRound(f, format, out, cliStats)
+}
+
+func runDump(inputs []input) error {
+	out, err := openOutput()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	for _, in := range inputs {
+		if dir, err := in.isDir(); err != nil {
+			return err
+		} else if dir {
+			return newUsageError("dump requires a replay file input")
+		}
+		f, err := in.open()
+		if err != nil {
+			return err
+		}
+		err = writeRoundDump(f, out)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runStats(inputs []input) error {
+	return encodeInputs(inputs, func(m *dissect.MatchReader, r *dissect.Reader) (any, error) {
+		if m != nil {
+			return m.PlayerStats(), nil
+		}
+		return r.PlayerStats(), nil
+	})
+}
+
+func runEvents(inputs []input) error {
+	types := viper.GetStringSlice("type")
+	filter := func(feedback []dissect.MatchUpdate) []dissect.MatchUpdate {
+		if len(types) == 0 {
+			return feedback
+		}
+		filtered := make([]dissect.MatchUpdate, 0)
+		for _, u := range feedback {
+			for _, t := range types {
+				if strings.EqualFold(u.Type.String(), t) {
+					filtered = append(filtered, u)
+					break
+				}
+			}
+		}
+		return filtered
+	}
+	type round struct {
+		Round         int                   `json:"round"`
+		MatchFeedback []dissect.MatchUpdate `json:"matchFeedback"`
+	}
+	return encodeInputs(inputs, func(m *dissect.MatchReader, r *dissect.Reader) (any, error) {
+		if m == nil {
+			return filter(r.MatchFeedback), nil
+		}
+		rounds := make([]round, 0, m.NumRounds())
+		for i := 0; i < m.NumRounds(); i++ {
+			r, err := m.RoundAt(i)
+			if !dissect.Ok(err) {
+				return nil, err
+			}
+			rounds = append(rounds, round{i + 1, filter(r.MatchFeedback)})
+		}
+		return rounds, nil
+	})
+}
+
+func runPlayers(inputs []input) error {
+	return encodeInputs(inputs, func(m *dissect.MatchReader, r *dissect.Reader) (any, error) {
+		if m == nil {
+			return r.Header.Players, nil
+		}
+		// players may join or leave between rounds
+		players := make([]dissect.Player, 0)
+		seen := make(map[string]bool)
+		for i := 0; i < m.NumRounds(); i++ {
+			r, err := m.RoundAt(i)
+			if !dissect.Ok(err) {
+				return nil, err
+			}
+			for _, p := range r.Header.Players {
+				key := p.ProfileID
+				if len(key) == 0 {
+					key = p.Username
+				}
+				if !seen[key] {
+					seen[key] = true
+					players = append(players, p)
+				}
+			}
+		}
+		return players, nil
+	})
+}
+
+// encodeInputs reads each input and writes the JSON returned by data,
+// one document per input.
+func encodeInputs(inputs []input, data func(m *dissect.MatchReader, r *dissect.Reader) (any, error)) error {
+	out, err := openOutput()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	encoder := json.NewEncoder(out)
+	for _, in := range inputs {
+		m, r, err := readInput(in)
+		if err != nil {
+			return err
+		}
+		v, err := data(m, r)
+		if err != nil {
+			return err
+		}
+		if err = encoder.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runIndex(inputs []input) error {
+	path := viper.GetString("index")
+	idx, err := dissect.OpenIndex(path)
+	if err != nil {
+		return err
+	}
+	if len(inputs) > 0 {
+		n := 0
+		for _, in := range inputs {
+			scanned, err := idx.Scan(in.path)
+			if err != nil {
+				return err
+			}
+			n += scanned
+		}
+		if err := idx.Save(path); err != nil {
+			return err
+		}
+		log.Info().Int("indexed", n).Int("total", len(idx.Matches)).Msg("index updated")
+	}
+	q := dissect.IndexQuery{
+		MatchType: viper.GetString("match-type"),
+		GameMode:  viper.GetString("game-mode"),
+		Map:       viper.GetString("map"),
+		Player:    viper.GetString("player"),
+	}
+	if q.Since, err = viperDate("since"); err != nil {
+		return err
+	}
+	if q.Until, err = viperDate("until"); err != nil {
+		return err
+	}
+	career := viper.GetBool("career")
+	// scanning without filters only updates the index
+	if len(inputs) > 0 && q == (dissect.IndexQuery{}) && !career {
+		return nil
+	}
+	out, err := openOutput()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "\t")
+	if career {
+		return encoder.Encode(idx.CareerStats(q, cliStats.match))
+	}
+	return encoder.Encode(idx.Query(q))
+}
+
+func viperDate(key string) (time.Time, error) {
+	val := viper.GetString(key)
+	if len(val) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, val)
+	if err != nil {
+		return t, newUsageError("invalid --%s date %q, expected YYYY-MM-DD", key, val)
+	}
+	return t, nil
+}
+
+func runWatch(inputs []input) error {
+	format, err := outputFormat()
+	if err != nil {
+		return err
+	}
+	return watch(inputs[0].path, viper.GetString("output"), format, viper.GetDuration("settle"), cliStats)
+}
+
+func runServe(_ []input) error {
+	return serve(
+		viper.GetString("addr"),
+		viper.GetInt("concurrency"),
+		viper.GetInt64("max-upload"),
+		viper.GetString("cache"),
+		viper.GetString("root"),
+		cliStats,
+	)
+}
+
+func printHead(in *os.File) error {
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		m, err := dissect.NewMatchReader(in)
+		if err != nil {
+			return err
+		}
+		r, err := m.FirstRound()
+		if err != nil {
+			return err
+		}
+		r.Head()
+		return nil
+	}
+	r, err := dissect.NewReader(in)
+	if err != nil {
+		return err
+	}
+	if err := r.ReadPartial(); !dissect.Ok(err) {
+		return err
+	}
+	r.Head()
+	return nil
+}
+
+func writeMatch(in *os.File, format OutputFormat, out io.Writer, opts statsOptions) error {
+	m, err := dissect.NewMatchReader(in)
+	if err != nil {
+		return err
+	}
+	opts.match(m)
+	if format == NDJSON {
+		return dissect.StreamMatch(m, out)
+	}
+	if err := m.Read(); !dissect.Ok(err) {
+		return err
+	}
+	return writeMatchData(m, format, out)
+}
+
+// writeMatchData writes a match that was already read.
+func writeMatchData(m *dissect.MatchReader, format OutputFormat, out io.Writer) error {
+	switch format {
+	case NDJSON:
+		return dissect.StreamMatch(m, out)
+	case Excel:
+		layout, err := excelLayout()
+		if err != nil {
+			return err
+		}
+		return m.WriteExcelLayout(out, layout)
+	case HTML, Markdown:
+		return writeReport(m.Report(), format, out)
+	}
+	return m.WriteJSON(out)
+}
+
+func writeRound(in io.Reader, format OutputFormat, out io.Writer, opts statsOptions) error {
+	r, err := dissect.NewReader(in)
+	if err != nil {
+		return err
+	}
+	opts.round(r)
+	if format == NDJSON {
+		return dissect.StreamRound(r, out)
+	}
+	if err := r.Read(); !dissect.Ok(err) {
+		return err
+	}
+	return writeRoundData(r, format, out)
+}
+
+// writeRoundData writes a round that was already read.
+func writeRoundData(r *dissect.Reader, format OutputFormat, out io.Writer) error {
+	switch format {
+	case NDJSON:
+		return dissect.StreamRound(r, out)
+	case Excel:
+		layout, err := excelLayout()
+		if err != nil {
+			return err
+		}
+		return r.WriteExcelLayout(out, layout)
+	case HTML, Markdown:
+		return writeReport(r.Report(), format, out)
+	}
+	return r.WriteJSON(out)
+}
+
+// statsOptions set how the stats of rounds and matches are derived.
+type statsOptions struct {
+	tradeWindow   float64                // seconds, see dissect.DefaultTradeWindow
+	ratingWeights *dissect.RatingWeights // dissect.DefaultRatingWeights when nil
+}
+
+// cliStats are the stats options set by --trade-window and --rating-weights.
+var cliStats statsOptions
+
+// loadStatsOptions returns the stats options set by --trade-window,
+// and the YAML/JSON file of rating weights specified by --rating-weights.
+// Weights missing from the file keep their default.
+func loadStatsOptions() (statsOptions, error) {
+	opts := statsOptions{tradeWindow: viper.GetFloat64("trade-window")}
+	path := viper.GetString("rating-weights")
+	if len(path) == 0 {
+		return opts, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return opts, err
+	}
+	weights := dissect.DefaultRatingWeights
+	if err := v.Unmarshal(&weights); err != nil {
+		return opts, err
+	}
+	opts.ratingWeights = &weights
+	return opts, nil
+}
+
+func (opts statsOptions) round(r *dissect.Reader) {
+	r.SetTradeWindow(opts.tradeWindow)
+	if opts.ratingWeights != nil {
+		r.SetRatingWeights(*opts.ratingWeights)
+	}
+}
+
+func (opts statsOptions) match(m *dissect.MatchReader) {
+	m.SetTradeWindow(opts.tradeWindow)
+	if opts.ratingWeights != nil {
+		m.SetRatingWeights(*opts.ratingWeights)
+	}
+}
+
+// writeReport writes a report with the built-in template,
+// or the template file specified by --template.
+func writeReport(report dissect.Report, format OutputFormat, out io.Writer) error {
+	path := viper.GetString("template")
+	if len(path) == 0 {
+		return report.WriteReport(out, dissect.ReportFormat(format))
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return report.WriteTemplate(out, dissect.ReportFormat(format), string(b))
+}
+
+// excelLayout returns the default workbook layout,
+// or the YAML/JSON layout file specified by --excel-layout.
+func excelLayout() (dissect.ExcelLayout, error) {
+	path := viper.GetString("excel-layout")
+	if len(path) == 0 {
+		return dissect.DefaultExcelLayout, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return dissect.ExcelLayout{}, err
+	}
+	var layout dissect.ExcelLayout
+	if err := v.Unmarshal(&layout); err != nil {
+		return layout, err
+	}
+	return layout, layout.Validate()
+}
+
+func writeRoundDump(in io.Reader, out *os.File) error {
+	r, err := dissect.NewReader(in)
+	if err != nil {
+		return err
+	}
+	_, err = r.Write(out)
+	return err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+	"github.com/spf13/viper"
+)
+
+func TestLoadStatsOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rating.yml")
+	if err := os.WriteFile(path, []byte("kill: 0.6\nopeningKill: 0.3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("trade-window", 3.0)
+	viper.Set("rating-weights", path)
+	t.Cleanup(viper.Reset)
+	opts, err := loadStatsOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.tradeWindow != 3 {
+		t.Errorf("tradeWindow: expected 3, got %v", opts.tradeWindow)
+	}
+	want := dissect.DefaultRatingWeights
+	want.Kill = 0.6
+	want.OpeningKill = 0.3
+	if opts.ratingWeights == nil || *opts.ratingWeights != want {
+		t.Errorf("ratingWeights: expected %+v, got %+v", want, opts.ratingWeights)
+	}
+	viper.Set("rating-weights", filepath.Join(t.TempDir(), "missing.yml"))
+	if _, err = loadStatsOptions(); err == nil {
+		t.Error("loadStatsOptions(): expected err for a missing file, got nil")
+	}
+}
+
+func TestExportPaths(t *testing.T) {
+	inputs := []input{
+		{filepath.Join("Match-1", "R01.rec")},
+		{filepath.Join("Match-2", "R01.rec")},
+		{filepath.Join("Match-3", "R01-2.rec")},
+		{filepath.Join("Match-4", "r01.rec")},
+		{},
+		{},
+	}
+	got := exportPaths(inputs, JSON, "out")
+	want := []string{"R01.json", "R01-2.json", "R01-2-2.json", "r01-3.json", "stdin.json", "stdin-2.json"}
+	for i, name := range want {
+		if got[i] != filepath.Join("out", name) {
+			t.Errorf("input %d: expected %s, got %s", i, filepath.Join("out", name), got[i])
+		}
+	}
+}
+
+func TestForEachParallel(t *testing.T) {
+	const n = 20
+	var mu sync.Mutex
+	running, peak := 0, 0
+	done := make([]bool, n)
+	forEachParallel(n, 4, func(i int) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		done[i] = true
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("job %d was not run", i)
+		}
+	}
+	if peak > 4 {
+		t.Errorf("expected at most 4 jobs at once, got %d", peak)
+	}
+}
+
+func TestExportToDir_Errors(t *testing.T) {
+	viper.Set("parallel", 3)
+	t.Cleanup(viper.Reset)
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inputs := []input{
+		{filepath.Join("examples", "unranked_R01.json")},
+		{filepath.Join(t.TempDir(), "missing.rec")},
+		{invalid},
+		{filepath.Join("examples", "unranked_R01.json")},
+	}
+	dir := t.TempDir()
+	errs, err := exportToDir(inputs, JSON, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || errs[3] != nil {
+		t.Errorf("expected the examples to export, got %v and %v", errs[0], errs[3])
+	}
+	if !errors.Is(errs[1], fs.ErrNotExist) {
+		t.Errorf("missing input: expected fs.ErrNotExist, got %v", errs[1])
+	}
+	if errs[2] == nil {
+		t.Error("invalid input: expected an error, got nil")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// outputs of failed inputs are removed, ReadDir sorts by name
+	want := []string{"unranked_R01.json-2.json", "unranked_R01.json.json"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected outputs %v, got %v", want, names)
+	}
+	if err = exportSummary(inputs, errs); err == nil || err.Error() != "2 of 4 inputs failed" {
+		t.Errorf("exportSummary(): expected 2 of 4 inputs to fail, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package dissect
+
+// AliveCount is the number of players alive on each team, e.g. [5 4] is 5v4.
+// Cause is the kill, death or player leaving that changed the count,
+// and is omitted for the count at the start of the round.
+type AliveCount struct {
+	Alive         [2]int       `json:"alive"`
+	Time          string       `json:"time"`
+	TimeInSeconds float64      `json:"timeInSeconds"`
+	Cause         *MatchUpdate `json:"cause,omitempty"`
+}
+
+// AliveTimeline returns the number of players alive on each team
+// at the start of the round and after every change.
+func (r *Reader) AliveTimeline() []AliveCount {
+	alive := make(map[string]bool)
+	count := [2]int{}
+	for _, p := range r.Header.Players {
+		if p.TeamIndex < 0 || p.TeamIndex > 1 {
+			continue
+		}
+		alive[p.Username] = true
+		count[p.TeamIndex]++
+	}
+	timeline := []AliveCount{{Alive: count}}
+	for _, a := range r.MatchFeedback {
+		username, ok := removedPlayer(a)
+		if !ok || !alive[username] {
+			continue
+		}
+		alive[username] = false
+		count[r.teamIndex(username)]--
+		cause := a
+		timeline = append(timeline, AliveCount{
+			Alive:         count,
+			Time:          a.Time,
+			TimeInSeconds: a.TimeInSeconds,
+			Cause:         &cause,
+		})
+	}
+	return timeline
+}
+
+// removedPlayer returns the player removed from the round by a kill,
+// death or player leaving.
+func removedPlayer(u MatchUpdate) (string, bool) {
+	switch u.Type {
+	case Kill:
+		return u.Target, true
+	case Death, PlayerLeave:
+		return u.Username, true
+	}
+	return "", false
+}
+
+// firstAdvantage returns the team that was first to have more players
+// alive than the other team after starting even, and the count at that time.
+func (r *Reader) firstAdvantage() (team int, at AliveCount, ok bool) {
+	timeline := r.AliveTimeline()
+	if start := timeline[0].Alive; start[0] != start[1] {
+		return -1, AliveCount{}, false
+	}
+	for _, c := range timeline[1:] {
+		if c.Alive[0] > c.Alive[1] {
+			return 0, c, true
+		} else if c.Alive[1] > c.Alive[0] {
+			return 1, c, true
+		}
+	}
+	return -1, AliveCount{}, false
+}
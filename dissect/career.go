@@ -0,0 +1,161 @@
+package dissect
+
+import (
+	"cmp"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CareerStats are the stats of a player across matches. Players are
+// keyed by profile ID, so stats recorded under previous usernames are
+// included and the usernames are listed in Usernames.
+type CareerStats struct {
+	ProfileID  string           `json:"profileID,omitempty"`
+	Username   string           `json:"username"` // the latest username
+	Usernames  []string         `json:"usernames"`
+	Matches    int              `json:"matches"`
+	FirstMatch time.Time        `json:"firstMatch"`
+	LastMatch  time.Time        `json:"lastMatch"`
+	Totals     PlayerMatchStats `json:"totals"`
+	Maps       []CareerSplit    `json:"maps"`
+	Operators  []CareerSplit    `json:"operators"`
+}
+
+// CareerSplit are the career stats of a player on a map or operator.
+type CareerSplit struct {
+	Name    string           `json:"name"`
+	Matches int              `json:"matches"`
+	Stats   PlayerMatchStats `json:"stats"`
+}
+
+// CareerStatsOf returns the career stats of every player in the matches,
+// ordered by rounds played. Matches should be in chronological order
+// for Username to be the latest username.
+func CareerStatsOf(matches ...*MatchReader) []CareerStats {
+	careers := make([]CareerStats, 0)
+	index := make(map[string]int)
+	for _, m := range matches {
+		played := make(map[string]bool) // players and their operators in the match
+		for _, r := range m.rounds {
+			for _, p := range r.PlayerStats() {
+				key := playerKey(p.ProfileID, p.Username)
+				i, ok := index[key]
+				if !ok {
+					i = len(careers)
+					index[key] = i
+					careers = append(careers, CareerStats{
+						ProfileID: p.ProfileID,
+						Usernames: make([]string, 0),
+						Totals:    PlayerMatchStats{ProfileID: p.ProfileID},
+						Maps:      make([]CareerSplit, 0),
+						Operators: make([]CareerSplit, 0),
+					})
+				}
+				c := &careers[i]
+				c.Username = p.Username
+				c.Totals.Username = p.Username
+				if !slices.Contains(c.Usernames, p.Username) {
+					c.Usernames = append(c.Usernames, p.Username)
+				}
+				firstRound := !played[key]
+				played[key] = true
+				if firstRound {
+					c.Matches++
+					c.addMatchTime(r.Header.Timestamp)
+				}
+				c.Totals.add(r, p)
+				mapSplit := careerSplit(&c.Maps, r.Header.Map.String())
+				if firstRound {
+					mapSplit.Matches++
+				}
+				mapSplit.Stats.add(r, p)
+				if len(p.Operator) > 0 {
+					operatorSplit := careerSplit(&c.Operators, p.Operator)
+					if !played[key+"\x00"+p.Operator] {
+						played[key+"\x00"+p.Operator] = true
+						operatorSplit.Matches++
+					}
+					operatorSplit.Stats.add(r, p)
+				}
+			}
+		}
+	}
+	for i := range careers {
+		c := &careers[i]
+		c.Totals.rates()
+		for _, splits := range [][]CareerSplit{c.Maps, c.Operators} {
+			for j := range splits {
+				splits[j].Stats.Username = c.Username
+				splits[j].Stats.ProfileID = c.ProfileID
+				splits[j].Stats.rates()
+			}
+			slices.SortStableFunc(splits, func(a, b CareerSplit) int {
+				return cmp.Compare(b.Stats.Rounds, a.Stats.Rounds)
+			})
+		}
+	}
+	slices.SortStableFunc(careers, func(a, b CareerStats) int {
+		return cmp.Compare(b.Totals.Rounds, a.Totals.Rounds)
+	})
+	return careers
+}
+
+// CareerStats returns the career stats of the players in the matches
+// matching q, e.g. in a date range. Matches that cannot be read are skipped.
+// configure, if not nil, is called with every match before its stats are
+// computed, e.g. to set the trade window.
+func (idx *Index) CareerStats(q IndexQuery, configure func(m *MatchReader)) []CareerStats {
+	matches := make([]*MatchReader, 0)
+	for _, entry := range idx.Query(q) {
+		m, err := entry.Open()
+		if err != nil {
+			log.Warn().Err(err).Str("path", entry.Path).Msg("skipping match folder")
+			continue
+		}
+		if configure != nil {
+			configure(m)
+		}
+		matches = append(matches, m)
+	}
+	return CareerStatsOf(matches...)
+}
+
+// Open reads the match folder of the entry.
+func (entry IndexEntry) Open() (*MatchReader, error) {
+	f, err := os.Open(entry.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := NewMatchReader(f)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.Read(); !Ok(err) {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *CareerStats) addMatchTime(t time.Time) {
+	if c.FirstMatch.IsZero() || t.Before(c.FirstMatch) {
+		c.FirstMatch = t
+	}
+	if t.After(c.LastMatch) {
+		c.LastMatch = t
+	}
+}
+
+// careerSplit returns the split named name, adding it if needed.
+func careerSplit(splits *[]CareerSplit, name string) *CareerSplit {
+	for i := range *splits {
+		if (*splits)[i].Name == name {
+			return &(*splits)[i]
+		}
+	}
+	*splits = append(*splits, CareerSplit{Name: name})
+	return &(*splits)[len(*splits)-1]
+}
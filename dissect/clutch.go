@@ -0,0 +1,65 @@
+package dissect
+
+type ClutchOutcome string
+
+const (
+	ClutchWon   ClutchOutcome = "won"
+	ClutchLost  ClutchOutcome = "lost"
+	ClutchSaved ClutchOutcome = "saved" // the round was lost but the player survived
+)
+
+// Clutch is a 1vX: the last player alive on a team
+// against Opponents players alive on the other team.
+type Clutch struct {
+	Username      string        `json:"username"`
+	TeamIndex     int           `json:"teamIndex"`
+	Opponents     int           `json:"opponents"`
+	Time          string        `json:"time"` // when the clutch started
+	TimeInSeconds float64       `json:"timeInSeconds"`
+	Kills         int           `json:"kills"` // kills during the clutch
+	Outcome       ClutchOutcome `json:"outcome"`
+}
+
+// Clutches returns the clutches of both teams in the order they started,
+// from the changes in the AliveTimeline of the round.
+func (r *Reader) Clutches() []Clutch {
+	clutches := make([]Clutch, 0)
+	removed := make(map[string]bool)
+	for _, c := range r.AliveTimeline()[1:] {
+		a := *c.Cause
+		username, _ := removedPlayer(a)
+		removed[username] = true
+		for i := range clutches {
+			if a.Type == Kill && a.Username == clutches[i].Username && r.opponentKill(a) {
+				clutches[i].Kills++
+			}
+		}
+		team := r.teamIndex(username)
+		if c.Alive[team] != 1 || c.Alive[1-team] == 0 {
+			continue
+		}
+		for _, p := range r.Header.Players {
+			if !removed[p.Username] && p.TeamIndex == team {
+				clutches = append(clutches, Clutch{
+					Username:      p.Username,
+					TeamIndex:     team,
+					Opponents:     c.Alive[1-team],
+					Time:          c.Time,
+					TimeInSeconds: c.TimeInSeconds,
+				})
+			}
+		}
+	}
+	for i := range clutches {
+		c := &clutches[i]
+		switch {
+		case r.Header.Teams[c.TeamIndex].Won:
+			c.Outcome = ClutchWon
+		case !removed[c.Username]:
+			c.Outcome = ClutchSaved
+		default:
+			c.Outcome = ClutchLost
+		}
+	}
+	return clutches
+}
@@ -0,0 +1,307 @@
+package dissect
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// CSV file names written by CreateCSVFiles.
+const (
+	MatchesCSV          = "matches.csv"
+	RoundsCSV           = "rounds.csv"
+	EventsCSV           = "events.csv"
+	PlayerRoundStatsCSV = "player_round_stats.csv"
+	PlayerMatchStatsCSV = "player_match_stats.csv"
+)
+
+// Column schemas of each CSV file. Rows reference each other by
+// match_id, round (1-based) and profile_id. New columns are only
+// ever appended to the end.
+var (
+	matchesColumns = []string{
+		"match_id", "timestamp", "game_version", "code_version",
+		"match_type", "match_type_id", "game_mode", "game_mode_id", "map", "map_id",
+		"recording_profile_id", "team0_name", "team1_name", "team0_score", "team1_score", "rounds",
+	}
+	roundsColumns = []string{
+		"match_id", "round", "overtime_round", "site", "team0_role", "team1_role",
+		"team0_score", "team1_score", "winning_team_index", "win_condition",
+	}
+	eventsColumns = []string{
+		"match_id", "round", "event_index", "type", "type_id",
+		"username", "profile_id", "target", "target_profile_id", "headshot",
+		"time", "time_in_seconds", "message", "operator", "operator_id",
+	}
+	playerRoundStatsColumns = []string{
+		"match_id", "round", "profile_id", "username", "team_index", "operator", "operator_id",
+		"score", "kills", "died", "assists", "headshots", "headshot_percentage", "one_vx",
+	}
+	playerMatchStatsColumns = []string{
+		"match_id", "profile_id", "username", "team_index",
+		"rounds", "kills", "deaths", "assists", "headshots", "headshot_percentage",
+	}
+)
+
+// CSVWriter writes matches and rounds to a set of related CSV tables.
+// Several matches may be written to the same CSVWriter.
+type CSVWriter struct {
+	matches          *csv.Writer
+	rounds           *csv.Writer
+	events           *csv.Writer
+	playerRoundStats *csv.Writer
+	playerMatchStats *csv.Writer
+	closers          []io.Closer
+	matchIDs         map[string]bool
+}
+
+// NewCSVWriter writes the header row of each table.
+func NewCSVWriter(matches, rounds, events, playerRoundStats, playerMatchStats io.Writer) (*CSVWriter, error) {
+	w := &CSVWriter{
+		matches:          csv.NewWriter(matches),
+		rounds:           csv.NewWriter(rounds),
+		events:           csv.NewWriter(events),
+		playerRoundStats: csv.NewWriter(playerRoundStats),
+		playerMatchStats: csv.NewWriter(playerMatchStats),
+		matchIDs:         make(map[string]bool),
+	}
+	headers := []struct {
+		w       *csv.Writer
+		columns []string
+	}{
+		{w.matches, matchesColumns},
+		{w.rounds, roundsColumns},
+		{w.events, eventsColumns},
+		{w.playerRoundStats, playerRoundStatsColumns},
+		{w.playerMatchStats, playerMatchStatsColumns},
+	}
+	for _, h := range headers {
+		if err := h.w.Write(h.columns); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// CreateCSVFiles creates dir and a CSVWriter for the tables inside.
+func CreateCSVFiles(dir string) (*CSVWriter, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	names := []string{MatchesCSV, RoundsCSV, EventsCSV, PlayerRoundStatsCSV, PlayerMatchStatsCSV}
+	files := make([]io.Writer, 0, len(names))
+	closers := make([]io.Closer, 0, len(names))
+	for _, name := range names {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			for _, c := range closers {
+				c.Close()
+			}
+			return nil, err
+		}
+		files = append(files, f)
+		closers = append(closers, f)
+	}
+	w, err := NewCSVWriter(files[0], files[1], files[2], files[3], files[4])
+	if err != nil {
+		for _, c := range closers {
+			c.Close()
+		}
+		return nil, err
+	}
+	w.closers = closers
+	return w, nil
+}
+
+// WriteMatch writes the match, its rounds, events and player stats.
+func (w *CSVWriter) WriteMatch(m *MatchReader) error {
+	if len(m.rounds) == 0 {
+		return nil
+	}
+	last := m.rounds[len(m.rounds)-1]
+	if err := w.writeMatchRow(last.Header, len(m.rounds)); err != nil {
+		return err
+	}
+	for _, r := range m.rounds {
+		if err := w.writeRoundRows(r); err != nil {
+			return err
+		}
+	}
+	for _, s := range m.PlayerStats() {
+		err := w.playerMatchStats.Write([]string{
+			last.Header.MatchID,
+			s.ProfileID,
+			s.Username,
+			strconv.Itoa(s.TeamIndex),
+			strconv.Itoa(s.Rounds),
+			strconv.Itoa(s.Kills),
+			strconv.Itoa(s.Deaths),
+			strconv.Itoa(s.Assists),
+			strconv.Itoa(s.Headshots),
+			csvFloat(s.HeadshotPercentage),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// WriteRound writes a single round. The match row is only written
+// for the first round of each match.
+func (w *CSVWriter) WriteRound(r *Reader) error {
+	if err := w.writeMatchRow(r.Header, 1); err != nil {
+		return err
+	}
+	if err := w.writeRoundRows(r); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (w *CSVWriter) writeMatchRow(h Header, rounds int) error {
+	if w.matchIDs[h.MatchID] {
+		return nil
+	}
+	w.matchIDs[h.MatchID] = true
+	return w.matches.Write([]string{
+		h.MatchID,
+		h.Timestamp.Format(time.RFC3339),
+		h.GameVersion,
+		strconv.Itoa(h.CodeVersion),
+		h.MatchType.String(),
+		strconv.Itoa(int(h.MatchType)),
+		h.GameMode.String(),
+		strconv.Itoa(int(h.GameMode)),
+		h.Map.String(),
+		strconv.Itoa(int(h.Map)),
+		h.RecordingProfileID,
+		h.Teams[0].Name,
+		h.Teams[1].Name,
+		strconv.Itoa(h.Teams[0].Score),
+		strconv.Itoa(h.Teams[1].Score),
+		strconv.Itoa(rounds),
+	})
+}
+
+func (w *CSVWriter) writeRoundRows(r *Reader) error {
+	h := r.Header
+	matchID := h.MatchID
+	round := strconv.Itoa(h.RoundNumber + 1)
+	winningTeamIndex := ""
+	winCondition := ""
+	for i, t := range h.Teams {
+		if t.Won {
+			winningTeamIndex = strconv.Itoa(i)
+			winCondition = string(t.WinCondition)
+		}
+	}
+	err := w.rounds.Write([]string{
+		matchID,
+		round,
+		strconv.Itoa(h.OvertimeRoundNumber),
+		h.Site,
+		string(h.Teams[0].Role),
+		string(h.Teams[1].Role),
+		strconv.Itoa(h.Teams[0].Score),
+		strconv.Itoa(h.Teams[1].Score),
+		winningTeamIndex,
+		winCondition,
+	})
+	if err != nil {
+		return err
+	}
+	for i, u := range r.MatchFeedback {
+		headshot := ""
+		if u.Headshot != nil {
+			headshot = strconv.FormatBool(*u.Headshot)
+		}
+		operator, operatorID := "", ""
+		if u.Operator != 0 {
+			operator = u.Operator.String()
+			operatorID = strconv.Itoa(int(u.Operator))
+		}
+		err := w.events.Write([]string{
+			matchID,
+			round,
+			strconv.Itoa(i),
+			u.Type.String(),
+			strconv.Itoa(int(u.Type)),
+			u.Username,
+			r.profileID(u.Username),
+			u.Target,
+			r.profileID(u.Target),
+			headshot,
+			u.Time,
+			csvFloat(u.TimeInSeconds),
+			u.Message,
+			operator,
+			operatorID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for i, s := range r.PlayerStats() {
+		operator := h.Players[i].Operator
+		err := w.playerRoundStats.Write([]string{
+			matchID,
+			round,
+			s.ProfileID,
+			s.Username,
+			strconv.Itoa(s.TeamIndex),
+			operator.String(),
+			strconv.Itoa(int(operator)),
+			strconv.Itoa(s.Score),
+			strconv.Itoa(s.Kills),
+			strconv.FormatBool(s.Died),
+			strconv.Itoa(s.Assists),
+			strconv.Itoa(s.Headshots),
+			csvFloat(s.HeadshotPercentage),
+			strconv.Itoa(s.OneVx),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *CSVWriter) Flush() error {
+	for _, c := range []*csv.Writer{w.matches, w.rounds, w.events, w.playerRoundStats, w.playerMatchStats} {
+		c.Flush()
+		if err := c.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the tables and closes files opened by CreateCSVFiles.
+func (w *CSVWriter) Close() error {
+	errs := []error{w.Flush()}
+	for _, c := range w.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Reader) profileID(username string) string {
+	if len(username) == 0 {
+		return ""
+	}
+	for _, p := range r.Header.Players {
+		if p.Username == username {
+			return p.ProfileID
+		}
+	}
+	return ""
+}
+
+func csvFloat(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
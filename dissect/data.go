@@ -0,0 +1,216 @@
+package dissect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+//go:generate go run ./genschema.go
+
+// SchemaVersion is the version of the JSON output described by RoundData
+// and MatchData. It is bumped whenever the shape of the output changes,
+// see the published schemas in /schema.
+const SchemaVersion = 10
+
+// RoundData is the JSON output of a round.
+type RoundData struct {
+	SchemaVersion int `json:"schemaVersion,omitempty"` // omitted from rounds in MatchData
+	Header
+	MatchFeedback []MatchUpdate      `json:"matchFeedback"`
+	PlayerStats   []PlayerRoundStats `json:"stats"`
+	AliveTimeline []AliveCount       `json:"aliveTimeline"`
+}
+
+// MatchData is the JSON output of a match.
+type MatchData struct {
+	SchemaVersion int                 `json:"schemaVersion"`
+	Rounds        []RoundData         `json:"rounds"`
+	PlayerStats   []PlayerMatchStats  `json:"stats"`
+	TeamStats     [2]TeamMatchStats   `json:"teamStats"`
+	OpeningDuels  [2]TeamOpeningDuels `json:"openingDuels"`
+	OperatorStats []OperatorStats     `json:"operatorStats"`
+	SiteStats     []SiteStats         `json:"siteStats"`
+}
+
+func (r *Reader) Data() RoundData {
+	return RoundData{
+		SchemaVersion: SchemaVersion,
+		Header:        r.Header,
+		MatchFeedback: r.MatchFeedback,
+		PlayerStats:   r.PlayerStats(),
+		AliveTimeline: r.AliveTimeline(),
+	}
+}
+
+func (r *Reader) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	return encoder.Encode(r.Data())
+}
+
+func (m *MatchReader) Data() MatchData {
+	rounds := make([]RoundData, 0)
+	for _, r := range m.rounds {
+		round := r.Data()
+		round.SchemaVersion = 0
+		rounds = append(rounds, round)
+	}
+	return MatchData{
+		SchemaVersion: SchemaVersion,
+		Rounds:        rounds,
+		PlayerStats:   m.PlayerStats(),
+		TeamStats:     m.TeamStats(),
+		OpeningDuels:  m.TeamOpeningDuels(),
+		OperatorStats: m.OperatorStats(),
+		SiteStats:     m.SiteStats(),
+	}
+}
+
+func (m *MatchReader) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	return encoder.Encode(m.Data())
+}
+
+// ReadRoundJSON loads a round exported as JSON. The scoreboard is restored
+// from the exported stats, so PlayerStats, Trades and WriteExcel work
+// without the original .rec file.
+func ReadRoundJSON(in io.Reader) (*Reader, error) {
+	var data RoundData
+	if err := decodeJSON(in, &data); err != nil {
+		return nil, err
+	}
+	return data.Reader(), nil
+}
+
+// ReadMatchJSON loads a match exported as JSON, see ReadRoundJSON.
+func ReadMatchJSON(in io.Reader) (*MatchReader, error) {
+	var data MatchData
+	if err := decodeJSON(in, &data); err != nil {
+		return nil, err
+	}
+	return data.MatchReader(), nil
+}
+
+// ReadJSON loads a match (m) or a round (r) exported as JSON.
+func ReadJSON(in io.Reader) (m *MatchReader, r *Reader, err error) {
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return
+	}
+	var probe struct {
+		Rounds json.RawMessage `json:"rounds"`
+	}
+	if err = json.Unmarshal(b, &probe); err != nil {
+		return
+	}
+	if probe.Rounds != nil {
+		var data MatchData
+		if err = decodeJSON(bytes.NewReader(b), &data); err != nil {
+			return
+		}
+		return data.MatchReader(), nil, nil
+	}
+	var data RoundData
+	if err = decodeJSON(bytes.NewReader(b), &data); err != nil {
+		return
+	}
+	return nil, data.Reader(), nil
+}
+
+// exportedData is the JSON output of a round or a match.
+type exportedData interface {
+	schemaVersion() int
+	Validate() error
+}
+
+func (d RoundData) schemaVersion() int { return d.SchemaVersion }
+
+func (d MatchData) schemaVersion() int { return d.SchemaVersion }
+
+// decodeJSON decodes an export into data, then checks
+// its schema version and validates it.
+func decodeJSON(in io.Reader, data exportedData) error {
+	if err := json.NewDecoder(in).Decode(data); err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(data.schemaVersion()); err != nil {
+		return err
+	}
+	return data.Validate()
+}
+
+// checkSchemaVersion accepts the current and older versions,
+// including exports from before schema versioning (0).
+func checkSchemaVersion(version int) error {
+	if version > SchemaVersion {
+		return fmt.Errorf("%w: %d (supports up to %d)", ErrUnsupportedSchema, version, SchemaVersion)
+	}
+	return nil
+}
+
+// Validate returns ErrInvalidData if the round has no players, a player
+// is not on either team, or a kill is missing its players or headshot.
+func (d RoundData) Validate() error {
+	if len(d.Players) == 0 {
+		return fmt.Errorf("%w: no players", ErrInvalidData)
+	}
+	for _, p := range d.Players {
+		if p.TeamIndex < 0 || p.TeamIndex > 1 {
+			return fmt.Errorf("%w: player %q has team index %d", ErrInvalidData, p.Username, p.TeamIndex)
+		}
+	}
+	for i, u := range d.MatchFeedback {
+		if u.Type != Kill {
+			continue
+		}
+		if len(u.Username) == 0 || len(u.Target) == 0 {
+			return fmt.Errorf("%w: kill %d is missing the username or target", ErrInvalidData, i)
+		}
+		if u.Headshot == nil {
+			return fmt.Errorf("%w: kill %d is missing headshot", ErrInvalidData, i)
+		}
+	}
+	return nil
+}
+
+// Validate validates every round, see RoundData.Validate.
+func (d MatchData) Validate() error {
+	if len(d.Rounds) == 0 {
+		return fmt.Errorf("%w: no rounds", ErrInvalidData)
+	}
+	for i, round := range d.Rounds {
+		if err := round.Validate(); err != nil {
+			return fmt.Errorf("round %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Reader returns a read Reader of the round data.
+func (d RoundData) Reader() *Reader {
+	r := &Reader{
+		Header:        d.Header,
+		MatchFeedback: d.MatchFeedback,
+	}
+	r.Scoreboard.Players = make([]ScoreboardPlayer, len(r.Header.Players))
+	for i, p := range r.Header.Players {
+		for _, s := range d.PlayerStats {
+			if s.Username == p.Username {
+				r.Scoreboard.Players[i].Score = uint32(s.Score)
+				r.Scoreboard.Players[i].AssistsFromRound = uint32(s.Assists)
+				break
+			}
+		}
+	}
+	return r
+}
+
+// MatchReader returns a read MatchReader of the match data.
+func (d MatchData) MatchReader() *MatchReader {
+	m := &MatchReader{rounds: make([]*Reader, len(d.Rounds))}
+	for i, round := range d.Rounds {
+		m.rounds[i] = round.Reader()
+	}
+	return m
+}
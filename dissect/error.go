@@ -10,6 +10,8 @@ import (
 var ErrInvalidFile = errors.New("dissect: not a dissect file")
 var ErrInvalidFolder = errors.New("dissect: not a match folder")
 var ErrInvalidStringSep = errors.New("dissect: invalid string separator")
+var ErrUnsupportedSchema = errors.New("dissect: unsupported schema version")
+var ErrInvalidData = errors.New("dissect: invalid round data")
 
 // Ok returns true if err only pertains to EOF (read was successful).
 func Ok(err error) bool {
@@ -0,0 +1,1214 @@
+package dissect
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xuri/excelize/v2"
+)
+
+// ExcelLayout describes the sheets of a workbook written by WriteExcelLayout.
+// It can be decoded from JSON, or YAML with viper.
+type ExcelLayout struct {
+	Sheets []ExcelSheet `json:"sheets" mapstructure:"sheets"`
+}
+
+type ExcelScope string
+
+const (
+	OverviewScope ExcelScope = "overview" // a single sheet with the data of every match, see WriteExcelMatches
+	MatchScope    ExcelScope = "match"    // a single sheet with the data of every round
+	RoundScope    ExcelScope = "round"    // a sheet per round
+)
+
+// ExcelSheet is a sheet of tables. {round} in the name of round
+// sheets is replaced by the round number. FreezeRows keeps the
+// top rows visible while scrolling.
+type ExcelSheet struct {
+	Name       string       `json:"name" mapstructure:"name"`
+	Scope      ExcelScope   `json:"scope" mapstructure:"scope"`
+	FreezeRows int          `json:"freezeRows,omitempty" mapstructure:"freezeRows"`
+	Tables     []ExcelTable `json:"tables" mapstructure:"tables"`
+}
+
+// ExcelTable is a titled table of rows from a source, see ExcelSources.
+// Tables are placed below the previous table unless Position (e.g. "K1")
+// is set. The default columns of the source are used when Columns is empty.
+//
+// GroupBy repeats the table for each value of a field, titled by the value.
+// SortBy orders the rows by a field, in descending order if Descending is set.
+// ListObject formats the table as an Excel table so it can be filtered.
+type ExcelTable struct {
+	Title      string        `json:"title" mapstructure:"title"`
+	Source     string        `json:"source" mapstructure:"source"`
+	Position   string        `json:"position,omitempty" mapstructure:"position"`
+	GroupBy    string        `json:"groupBy,omitempty" mapstructure:"groupBy"`
+	SortBy     string        `json:"sortBy,omitempty" mapstructure:"sortBy"`
+	Descending bool          `json:"descending,omitempty" mapstructure:"descending"`
+	ListObject bool          `json:"listObject,omitempty" mapstructure:"listObject"`
+	Columns    []ExcelColumn `json:"columns,omitempty" mapstructure:"columns"`
+	Charts     []ExcelChart  `json:"charts,omitempty" mapstructure:"charts"`
+}
+
+type ExcelColumn struct {
+	Header    string           `json:"header" mapstructure:"header"`
+	Field     string           `json:"field" mapstructure:"field"`
+	Highlight []ExcelHighlight `json:"highlight,omitempty" mapstructure:"highlight"`
+}
+
+// ExcelHighlight fills the cells of a column equal to Equals with Color (e.g. "#C6EFCE").
+type ExcelHighlight struct {
+	Equals any    `json:"equals" mapstructure:"equals"`
+	Color  string `json:"color" mapstructure:"color"`
+}
+
+// ExcelChart plots fields of a table against Category, which is usually "round".
+// Charts are placed to the right of the table unless Position is set.
+type ExcelChart struct {
+	Type     string   `json:"type" mapstructure:"type"` // line, col, bar or area
+	Title    string   `json:"title" mapstructure:"title"`
+	Position string   `json:"position,omitempty" mapstructure:"position"`
+	Category string   `json:"category" mapstructure:"category"`
+	Values   []string `json:"values" mapstructure:"values"`
+}
+
+var excelChartTypes = map[string]excelize.ChartType{
+	"line": excelize.Line,
+	"col":  excelize.Col,
+	"bar":  excelize.Bar,
+	"area": excelize.Area,
+}
+
+var (
+	excelWin  = ExcelHighlight{Equals: true, Color: "#C6EFCE"}
+	excelLoss = ExcelHighlight{Equals: false, Color: "#FFC7CE"}
+)
+
+// DefaultExcelLayout is the layout used by WriteExcel and WriteExcelMatches.
+var DefaultExcelLayout = ExcelLayout{
+	Sheets: []ExcelSheet{
+		{
+			Name:  "Overview",
+			Scope: OverviewScope,
+			Tables: []ExcelTable{
+				{Title: "Matches", Source: "matches", ListObject: true},
+				{Title: "Players", Source: "playerTotals", ListObject: true},
+				{Title: "Operators", Source: "operatorStats", ListObject: true},
+				{Title: "Sites", Source: "siteStats", ListObject: true},
+				{Title: "Attack spawns", Source: "spawnStats", ListObject: true},
+			},
+		},
+		{
+			Name:       "Match",
+			Scope:      MatchScope,
+			FreezeRows: 2,
+			Tables: []ExcelTable{
+				{Title: "Statistics", Source: "playerMatchStats", SortBy: "rating", Descending: true, ListObject: true},
+				{Title: "Teams", Source: "teamStats", ListObject: true},
+				{
+					Title:      "Rounds",
+					Source:     "rounds",
+					ListObject: true,
+					Columns: []ExcelColumn{
+						{Header: "Round", Field: "round"},
+						{Header: "Site", Field: "site"},
+						{Header: "Winner", Field: "winner"},
+						{Header: "Win condition", Field: "winCondition"},
+						{Header: "Team 1 score", Field: "team0Score"},
+						{Header: "Team 2 score", Field: "team1Score"},
+						{Header: "Team 1 kills", Field: "team0Kills"},
+						{Header: "Team 2 kills", Field: "team1Kills"},
+					},
+					Charts: []ExcelChart{
+						{Type: "line", Title: "Score", Category: "round", Values: []string{"team0Score", "team1Score"}},
+						{Type: "col", Title: "Kills per round", Category: "round", Values: []string{"team0Kills", "team1Kills"}},
+					},
+				},
+				{Title: "Clutches", Source: "clutches", ListObject: true},
+			},
+		},
+		{
+			Name:  "Players",
+			Scope: MatchScope,
+			Tables: []ExcelTable{
+				{
+					Source:     "playerRoundStats",
+					GroupBy:    "username",
+					ListObject: true,
+					Columns: []ExcelColumn{
+						{Header: "Round", Field: "round"},
+						{Header: "Operator", Field: "operator"},
+						{Header: "Kills", Field: "kills"},
+						{Header: "Deaths", Field: "deaths"},
+						{Header: "Assists", Field: "assists"},
+						{Header: "Survived", Field: "survived"},
+						{Header: "KOST", Field: "kost"},
+						{Header: "Opening kill", Field: "openingKill"},
+						{Header: "Opening death", Field: "openingDeath"},
+						{Header: "Rating", Field: "rating"},
+						{Header: "Won", Field: "won", Highlight: []ExcelHighlight{excelWin, excelLoss}},
+					},
+				},
+			},
+		},
+		{
+			Name:  "Operators",
+			Scope: MatchScope,
+			Tables: []ExcelTable{
+				{Title: "Operators", Source: "operatorStats", ListObject: true},
+				{Title: "Operators by player", Source: "playerOperatorStats", ListObject: true},
+			},
+		},
+		{
+			Name:  "Sites",
+			Scope: MatchScope,
+			Tables: []ExcelTable{
+				{Title: "Sites", Source: "siteStats", ListObject: true},
+				{Title: "Attack spawns", Source: "spawnStats", ListObject: true},
+			},
+		},
+		{
+			Name:       "Round {round}",
+			Scope:      RoundScope,
+			FreezeRows: 2,
+			Tables: []ExcelTable{
+				{Title: "Statistics", Source: "playerRoundStats", ListObject: true},
+				{Title: "Round info", Source: "roundInfo"},
+				{Title: "Kill/death feed", Source: "killFeed", ListObject: true},
+				{Title: "Players alive", Source: "aliveTimeline", ListObject: true},
+				{Title: "Trades", Source: "trades", Position: "K1", ListObject: true},
+			},
+		},
+	},
+}
+
+type excelRow map[string]any
+
+type excelSource struct {
+	fields  []string      // every field of the rows
+	columns []ExcelColumn // default columns
+	rows    func(rounds []*Reader) []excelRow
+}
+
+// ExcelSources returns the names of the sources available to tables,
+// mapped to the fields of their rows.
+func ExcelSources() map[string][]string {
+	sources := make(map[string][]string, len(excelSources))
+	for name, source := range excelSources {
+		sources[name] = source.fields
+	}
+	return sources
+}
+
+var excelSources = map[string]excelSource{
+	"matches": {
+		fields: []string{"match", "matchID", "date", "map", "gameMode", "matchType", "team0", "team1", "team0Score", "team1Score", "winner", "rounds"},
+		columns: excelColumns(
+			"Match", "match", "Date", "date", "Map", "map", "Game mode", "gameMode", "Team 1", "team0", "Team 2", "team1",
+			"Team 1 score", "team0Score", "Team 2 score", "team1Score", "Winner", "winner", "Rounds", "rounds",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for i, match := range splitMatches(rounds) {
+				first := match[0].Header
+				last := match[len(match)-1].Header
+				row := excelRow{
+					"match":      i + 1,
+					"matchID":    first.MatchID,
+					"date":       first.Timestamp.Format(time.DateTime),
+					"map":        first.Map.String(),
+					"gameMode":   first.GameMode.String(),
+					"matchType":  first.MatchType.String(),
+					"team0":      last.Teams[0].Name,
+					"team1":      last.Teams[1].Name,
+					"team0Score": last.Teams[0].Score,
+					"team1Score": last.Teams[1].Score,
+					"winner":     "",
+					"rounds":     len(match),
+				}
+				if last.Teams[0].Score > last.Teams[1].Score {
+					row["winner"] = last.Teams[0].Name
+				} else if last.Teams[1].Score > last.Teams[0].Score {
+					row["winner"] = last.Teams[1].Name
+				}
+				rows = append(rows, row)
+			}
+			return rows
+		},
+	},
+	"playerTotals": {
+		fields: []string{"profileID", "username", "matches", "rounds", "kills", "deaths", "assists", "headshotPercentage", "headshots"},
+		columns: excelColumns(
+			"Player", "username", "Matches", "matches", "Rounds", "rounds", "Kills", "kills", "Deaths", "deaths",
+			"Assists", "assists", "Hs%", "headshotPercentage", "Headshots", "headshots",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			totals := make([]PlayerMatchStats, 0)
+			matches := make([]int, 0)
+			// players are grouped by profile ID so name changes don't split their stats
+			index := make(map[string]int)
+			for _, match := range splitMatches(rounds) {
+				m := &MatchReader{rounds: match}
+				for _, s := range m.PlayerStats() {
+					key := playerKey(s.ProfileID, s.Username)
+					i, ok := index[key]
+					if !ok {
+						i = len(totals)
+						index[key] = i
+						totals = append(totals, PlayerMatchStats{ProfileID: s.ProfileID})
+						matches = append(matches, 0)
+					}
+					t := &totals[i]
+					t.Username = s.Username
+					t.Rounds += s.Rounds
+					t.Kills += s.Kills
+					t.Deaths += s.Deaths
+					t.Assists += s.Assists
+					t.Headshots += s.Headshots
+					matches[i]++
+				}
+			}
+			rows := make([]excelRow, 0, len(totals))
+			for i, t := range totals {
+				rows = append(rows, excelRow{
+					"profileID":          t.ProfileID,
+					"username":           t.Username,
+					"matches":            matches[i],
+					"rounds":             t.Rounds,
+					"kills":              t.Kills,
+					"deaths":             t.Deaths,
+					"assists":            t.Assists,
+					"headshotPercentage": headshotPercentage(t.Headshots, t.Kills),
+					"headshots":          t.Headshots,
+				})
+			}
+			return rows
+		},
+	},
+	"playerMatchStats": {
+		fields: []string{
+			"username", "profileID", "teamIndex", "team", "rounds", "kills", "deaths", "assists", "headshotPercentage", "headshots",
+			"kost", "kpr", "dpr", "apr", "survivalRate", "2k", "3k", "4k", "5k", "clutchAttempts", "clutchWins",
+			"entryAttempts", "entryWins", "entryLosses", "entrySuccessRate", "attackEntrySuccessRate", "defenseEntrySuccessRate",
+			"tradeKills", "tradedDeaths", "untradedDeaths", "rating",
+		},
+		columns: excelColumns(
+			"Player", "username", "Team Index", "teamIndex", "Rating", "rating", "Rounds", "rounds", "Kills", "kills",
+			"Deaths", "deaths", "Assists", "assists", "Hs%", "headshotPercentage", "Headshots", "headshots",
+			"KOST%", "kost", "KPR", "kpr", "Survival%", "survivalRate", "2K", "2k", "3K", "3k", "4K", "4k", "5K", "5k",
+			"Clutches", "clutchAttempts", "Clutches won", "clutchWins", "Entries", "entryAttempts", "Entry%", "entrySuccessRate",
+			"Trade kills", "tradeKills", "Traded deaths", "tradedDeaths",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			m := &MatchReader{rounds: rounds}
+			rows := make([]excelRow, 0)
+			for _, s := range m.PlayerStats() {
+				log.Debug().Interface("match_player_stats", s).Send()
+				rows = append(rows, excelRow{
+					"username":                s.Username,
+					"profileID":               s.ProfileID,
+					"teamIndex":               s.TeamIndex,
+					"team":                    rounds[len(rounds)-1].Header.Teams[s.TeamIndex].Name,
+					"rounds":                  s.Rounds,
+					"kills":                   s.Kills,
+					"deaths":                  s.Deaths,
+					"assists":                 s.Assists,
+					"headshotPercentage":      s.HeadshotPercentage,
+					"headshots":               s.Headshots,
+					"kost":                    s.KOST,
+					"kpr":                     s.KPR,
+					"dpr":                     s.DPR,
+					"apr":                     s.APR,
+					"survivalRate":            s.SurvivalRate,
+					"2k":                      s.TwoKills,
+					"3k":                      s.ThreeKills,
+					"4k":                      s.FourKills,
+					"5k":                      s.FiveKills,
+					"clutchAttempts":          s.ClutchAttempts,
+					"clutchWins":              s.ClutchWins,
+					"entryAttempts":           s.OpeningDuels.Total.Attempts,
+					"entryWins":               s.OpeningDuels.Total.Wins,
+					"entryLosses":             s.OpeningDuels.Total.Losses,
+					"entrySuccessRate":        s.OpeningDuels.Total.SuccessRate,
+					"attackEntrySuccessRate":  s.OpeningDuels.Attack.SuccessRate,
+					"defenseEntrySuccessRate": s.OpeningDuels.Defense.SuccessRate,
+					"tradeKills":              s.TradeKills,
+					"tradedDeaths":            s.TradedDeaths,
+					"untradedDeaths":          s.UntradedDeaths,
+					"rating":                  s.Rating,
+				})
+			}
+			return rows
+		},
+	},
+	"playerRoundStats": {
+		fields: []string{"round", "username", "profileID", "teamIndex", "team", "score", "kills", "died", "deaths", "survived", "assists", "headshotPercentage", "headshots", "oneVx", "operator", "openingKill", "openingDeath", "won", "traded", "tradeKills", "planted", "defused", "kost", "clutch", "clutchOutcome", "rating"},
+		columns: excelColumns(
+			"Player", "username", "Team Index", "teamIndex", "Kills", "kills", "Died", "died", "Assists", "assists",
+			"Hs%", "headshotPercentage", "Headshots", "headshots", "1vX", "oneVx", "Operator", "operator", "Rating", "rating",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				for _, s := range r.PlayerStats() {
+					log.Debug().Interface("round_player_stats", s).Send()
+					deaths := 0
+					if s.Died {
+						deaths = 1
+					}
+					rows = append(rows, excelRow{
+						"round":              r.Header.RoundNumber + 1,
+						"username":           s.Username,
+						"profileID":          s.ProfileID,
+						"teamIndex":          s.TeamIndex,
+						"team":               r.Header.Teams[s.TeamIndex].Name,
+						"score":              s.Score,
+						"kills":              s.Kills,
+						"died":               s.Died,
+						"deaths":             deaths,
+						"survived":           !s.Died,
+						"assists":            s.Assists,
+						"headshotPercentage": s.HeadshotPercentage,
+						"headshots":          s.Headshots,
+						"oneVx":              s.OneVx,
+						"operator":           s.Operator,
+						"openingKill":        s.OpeningKill,
+						"openingDeath":       s.OpeningDeath,
+						"won":                r.Header.Teams[s.TeamIndex].Won,
+						"traded":             s.Traded,
+						"tradeKills":         s.TradeKills,
+						"planted":            s.Planted,
+						"defused":            s.Defused,
+						"kost":               s.KOST,
+						"rating":             s.Rating,
+					})
+					if s.Clutch != nil {
+						rows[len(rows)-1]["clutch"] = fmt.Sprintf("1v%d", s.Clutch.Opponents)
+						rows[len(rows)-1]["clutchOutcome"] = string(s.Clutch.Outcome)
+					}
+				}
+			}
+			return rows
+		},
+	},
+	"teamStats": {
+		fields: []string{
+			"teamIndex", "team", "rounds", "roundsWon", "attackRounds", "attackWins", "attackWinRate", "defenseRounds", "defenseWins", "defenseWinRate",
+			"killedOpponents", "disabledDefuser", "defusedBomb", "time", "plants", "plantWins", "plantConversion", "retakes", "retakeWins", "retakeSuccessRate",
+			"openingDuelWins", "openingDuelLosses", "openingDuelWinRate", "averageFirstKill", "manAdvantageRounds", "manAdvantageWins", "manAdvantageConversion",
+			"firstAdvantageRounds", "firstAdvantageWins", "firstAdvantageWinRate", "firstDeficitRounds", "firstDeficitWins", "firstDeficitWinRate",
+		},
+		columns: excelColumns(
+			"Team", "team", "Rounds won", "roundsWon", "Attack win %", "attackWinRate", "Defense win %", "defenseWinRate",
+			"Plants", "plants", "Plant conversion %", "plantConversion", "Retakes", "retakes", "Retake %", "retakeSuccessRate",
+			"Opening duel %", "openingDuelWinRate", "First kill (s)", "averageFirstKill", "5v4 conversion %", "manAdvantageConversion",
+			"First down win %", "firstDeficitWinRate",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			m := &MatchReader{rounds: rounds}
+			rows := make([]excelRow, 0)
+			for i, t := range m.TeamStats() {
+				rows = append(rows, excelRow{
+					"teamIndex":              i,
+					"team":                   t.Name,
+					"rounds":                 t.Rounds,
+					"roundsWon":              t.RoundsWon,
+					"attackRounds":           t.AttackRounds,
+					"attackWins":             t.AttackWins,
+					"attackWinRate":          t.AttackWinRate,
+					"defenseRounds":          t.DefenseRounds,
+					"defenseWins":            t.DefenseWins,
+					"defenseWinRate":         t.DefenseWinRate,
+					"killedOpponents":        t.WinConditions[KilledOpponents],
+					"disabledDefuser":        t.WinConditions[DisabledDefuser],
+					"defusedBomb":            t.WinConditions[DefusedBomb],
+					"time":                   t.WinConditions[Time],
+					"plants":                 t.Plants,
+					"plantWins":              t.PlantWins,
+					"plantConversion":        t.PlantConversion,
+					"retakes":                t.Retakes,
+					"retakeWins":             t.RetakeWins,
+					"retakeSuccessRate":      t.RetakeSuccessRate,
+					"openingDuelWins":        t.OpeningDuelWins,
+					"openingDuelLosses":      t.OpeningDuelLosses,
+					"openingDuelWinRate":     t.OpeningDuelWinRate,
+					"averageFirstKill":       t.AverageFirstKill,
+					"manAdvantageRounds":     t.ManAdvantageRounds,
+					"manAdvantageWins":       t.ManAdvantageWins,
+					"manAdvantageConversion": t.ManAdvantageConversion,
+					"firstAdvantageRounds":   t.FirstAdvantageRounds,
+					"firstAdvantageWins":     t.FirstAdvantageWins,
+					"firstAdvantageWinRate":  t.FirstAdvantageWinRate,
+					"firstDeficitRounds":     t.FirstDeficitRounds,
+					"firstDeficitWins":       t.FirstDeficitWins,
+					"firstDeficitWinRate":    t.FirstDeficitWinRate,
+				})
+			}
+			return rows
+		},
+	},
+	"roundInfo": {
+		fields:  []string{"name", "value", "time"},
+		columns: excelColumns("Name", "name", "Value", "value", "Time", "time"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				rows = append(rows, roundInfoRows(r)...)
+			}
+			return rows
+		},
+	},
+	"killFeed": {
+		fields:  []string{"round", "type", "username", "target", "time", "headshot"},
+		columns: excelColumns("Player", "username", "Target", "target", "Time", "time", "Headshot", "headshot"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				for _, a := range r.KillsAndDeaths() {
+					row := excelRow{
+						"round":    r.Header.RoundNumber + 1,
+						"type":     a.Type.String(),
+						"username": a.Username,
+						"target":   "",
+						"time":     a.Time,
+						"headshot": a.Type == Kill && a.Headshot != nil && *a.Headshot,
+					}
+					if a.Type == Kill {
+						row["target"] = a.Target
+					}
+					rows = append(rows, row)
+				}
+			}
+			return rows
+		},
+	},
+	"aliveTimeline": {
+		fields:  []string{"round", "team0Alive", "team1Alive", "alive", "time", "type", "username", "target"},
+		columns: excelColumns("Alive", "alive", "Time", "time", "Event", "type", "Player", "username", "Target", "target"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				for _, c := range r.AliveTimeline() {
+					row := excelRow{
+						"round":      r.Header.RoundNumber + 1,
+						"team0Alive": c.Alive[0],
+						"team1Alive": c.Alive[1],
+						"alive":      fmt.Sprintf("%dv%d", c.Alive[0], c.Alive[1]),
+						"time":       c.Time,
+					}
+					if c.Cause != nil {
+						row["type"] = c.Cause.Type.String()
+						row["username"] = c.Cause.Username
+						row["target"] = c.Cause.Target
+					}
+					rows = append(rows, row)
+				}
+			}
+			return rows
+		},
+	},
+	"trades": {
+		fields:  []string{"round", "traded", "trader", "killer", "time", "seconds"},
+		columns: excelColumns("Traded", "traded", "Trader", "trader", "Killer", "killer", "Time", "time"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				for _, trade := range r.TradesWithin(r.TradeWindow()) {
+					rows = append(rows, excelRow{
+						"round":   r.Header.RoundNumber + 1,
+						"traded":  trade.Traded,
+						"trader":  trade.Trader,
+						"killer":  trade.Killer,
+						"time":    trade.Refrag.Time,
+						"seconds": trade.Seconds,
+					})
+				}
+			}
+			return rows
+		},
+	},
+	"clutches": {
+		fields:  []string{"round", "username", "teamIndex", "team", "opponents", "clutch", "time", "kills", "outcome"},
+		columns: excelColumns("Round", "round", "Player", "username", "Clutch", "clutch", "Time", "time", "Kills", "kills", "Outcome", "outcome"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				for _, c := range r.Clutches() {
+					rows = append(rows, excelRow{
+						"round":     r.Header.RoundNumber + 1,
+						"username":  c.Username,
+						"teamIndex": c.TeamIndex,
+						"team":      r.Header.Teams[c.TeamIndex].Name,
+						"opponents": c.Opponents,
+						"clutch":    fmt.Sprintf("1v%d", c.Opponents),
+						"time":      c.Time,
+						"kills":     c.Kills,
+						"outcome":   string(c.Outcome),
+					})
+				}
+			}
+			return rows
+		},
+	},
+	"events": {
+		fields:  []string{"round", "type", "username", "target", "time", "headshot", "message", "operator"},
+		columns: excelColumns("Type", "type", "Player", "username", "Target", "target", "Time", "time", "Message", "message"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				for _, u := range r.MatchFeedback {
+					row := excelRow{
+						"round":    r.Header.RoundNumber + 1,
+						"type":     u.Type.String(),
+						"username": u.Username,
+						"target":   u.Target,
+						"time":     u.Time,
+						"message":  u.Message,
+					}
+					if u.Headshot != nil {
+						row["headshot"] = *u.Headshot
+					}
+					if u.Operator != 0 {
+						row["operator"] = u.Operator.String()
+					}
+					rows = append(rows, row)
+				}
+			}
+			return rows
+		},
+	},
+	"operatorPicks": {
+		fields:  []string{"round", "username", "teamIndex", "team", "role", "operator"},
+		columns: excelColumns("Round", "round", "Player", "username", "Role", "role", "Operator", "operator"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				for _, p := range r.Header.Players {
+					rows = append(rows, excelRow{
+						"round":     r.Header.RoundNumber + 1,
+						"username":  p.Username,
+						"teamIndex": p.TeamIndex,
+						"team":      r.Header.Teams[p.TeamIndex].Name,
+						"role":      string(r.Header.Teams[p.TeamIndex].Role),
+						"operator":  p.Operator.String(),
+					})
+				}
+			}
+			return rows
+		},
+	},
+	"operatorStats": {
+		fields: []string{"operator", "role", "picks", "roundsWon", "winRate", "kills", "deaths", "kd", "survivalRate", "openingKills", "openingKillRate"},
+		columns: excelColumns(
+			"Operator", "operator", "Role", "role", "Picks", "picks", "Win %", "winRate",
+			"K/D", "kd", "Survival %", "survivalRate", "Opening kill %", "openingKillRate",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, o := range operatorStats(rounds) {
+				row := operatorRecordRow(o.OperatorRecord)
+				row["operator"] = o.Operator.String()
+				row["role"] = string(o.Role)
+				rows = append(rows, row)
+			}
+			return rows
+		},
+	},
+	"playerOperatorStats": {
+		fields: []string{"username", "profileID", "operator", "role", "picks", "roundsWon", "winRate", "kills", "deaths", "kd", "survivalRate", "openingKills", "openingKillRate"},
+		columns: excelColumns(
+			"Player", "username", "Operator", "operator", "Role", "role", "Picks", "picks", "Win %", "winRate",
+			"K/D", "kd", "Survival %", "survivalRate", "Opening kill %", "openingKillRate",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, o := range operatorStats(rounds) {
+				for _, p := range o.Players {
+					row := operatorRecordRow(p.OperatorRecord)
+					row["username"] = p.Username
+					row["profileID"] = p.ProfileID
+					row["operator"] = o.Operator.String()
+					row["role"] = string(o.Role)
+					rows = append(rows, row)
+				}
+			}
+			return rows
+		},
+	},
+	"siteStats": {
+		fields: []string{"map", "site", "rounds", "pickRate", "attackWins", "defenseWins", "attackWinRate", "plants", "plantRate", "postPlantWins", "postPlantWinRate"},
+		columns: excelColumns(
+			"Map", "map", "Site", "site", "Rounds", "rounds", "Pick %", "pickRate", "Attack win %", "attackWinRate",
+			"Plant %", "plantRate", "Post-plant win %", "postPlantWinRate",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, s := range siteStats(rounds) {
+				rows = append(rows, excelRow{
+					"map":              s.Map.String(),
+					"site":             s.Site,
+					"rounds":           s.Rounds,
+					"pickRate":         s.PickRate,
+					"attackWins":       s.AttackWins,
+					"defenseWins":      s.DefenseWins,
+					"attackWinRate":    s.AttackWinRate,
+					"plants":           s.Plants,
+					"plantRate":        s.PlantRate,
+					"postPlantWins":    s.PostPlantWins,
+					"postPlantWinRate": s.PostPlantWinRate,
+				})
+			}
+			return rows
+		},
+	},
+	"spawnStats": {
+		fields:  []string{"map", "site", "spawn", "picks", "roundsWon", "winRate"},
+		columns: excelColumns("Map", "map", "Site", "site", "Spawn", "spawn", "Picks", "picks", "Win %", "winRate"),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, s := range siteStats(rounds) {
+				for _, spawn := range s.Spawns {
+					rows = append(rows, excelRow{
+						"map":       s.Map.String(),
+						"site":      s.Site,
+						"spawn":     spawn.Spawn,
+						"picks":     spawn.Picks,
+						"roundsWon": spawn.RoundsWon,
+						"winRate":   spawn.WinRate,
+					})
+				}
+			}
+			return rows
+		},
+	},
+	"rounds": {
+		fields: []string{"round", "site", "winner", "winningTeamIndex", "winCondition", "team0", "team1", "team0Score", "team1Score", "team0Kills", "team1Kills"},
+		columns: excelColumns(
+			"Round", "round", "Site", "site", "Winner", "winner", "Win condition", "winCondition",
+			"Score 1", "team0Score", "Score 2", "team1Score",
+		),
+		rows: func(rounds []*Reader) []excelRow {
+			rows := make([]excelRow, 0)
+			for _, r := range rounds {
+				h := r.Header
+				kills := [2]int{}
+				for _, u := range r.MatchFeedback {
+					if u.Type != Kill {
+						continue
+					}
+					if i := r.teamIndex(u.Username); i >= 0 {
+						kills[i]++
+					}
+				}
+				row := excelRow{
+					"round":      h.RoundNumber + 1,
+					"site":       h.Site,
+					"team0":      h.Teams[0].Name,
+					"team1":      h.Teams[1].Name,
+					"team0Score": h.Teams[0].Score,
+					"team1Score": h.Teams[1].Score,
+					"team0Kills": kills[0],
+					"team1Kills": kills[1],
+				}
+				for i, t := range h.Teams {
+					if t.Won {
+						row["winner"] = t.Name
+						row["winningTeamIndex"] = i
+						row["winCondition"] = string(t.WinCondition)
+					}
+				}
+				rows = append(rows, row)
+			}
+			return rows
+		},
+	},
+}
+
+// splitMatches splits rounds into matches by match ID.
+func splitMatches(rounds []*Reader) [][]*Reader {
+	matches := make([][]*Reader, 0)
+	index := make(map[string]int)
+	for _, r := range rounds {
+		i, ok := index[r.Header.MatchID]
+		if !ok {
+			i = len(matches)
+			index[r.Header.MatchID] = i
+			matches = append(matches, nil)
+		}
+		matches[i] = append(matches[i], r)
+	}
+	return matches
+}
+
+// excelColumns returns columns from pairs of headers and fields.
+func excelColumns(pairs ...string) []ExcelColumn {
+	columns := make([]ExcelColumn, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		columns = append(columns, ExcelColumn{Header: pairs[i], Field: pairs[i+1]})
+	}
+	return columns
+}
+
+func operatorRecordRow(o OperatorRecord) excelRow {
+	return excelRow{
+		"picks":           o.Picks,
+		"roundsWon":       o.RoundsWon,
+		"winRate":         o.WinRate,
+		"kills":           o.Kills,
+		"deaths":          o.Deaths,
+		"kd":              o.KD,
+		"survivalRate":    o.SurvivalRate,
+		"openingKills":    o.OpeningKills,
+		"openingKillRate": o.OpeningKillRate,
+	}
+}
+
+func roundInfoRows(r *Reader) []excelRow {
+	openingKill := r.OpeningKill()
+	openingDeath := r.OpeningDeath()
+	winningTeamIndex := 0
+	if r.Header.Teams[1].Won {
+		winningTeamIndex = 1
+	}
+	rows := []excelRow{
+		{"name": "Site", "value": r.Header.Site},
+		{"name": "Winning team", "value": fmt.Sprintf("%s [%d]", r.Header.Teams[winningTeamIndex].Name, winningTeamIndex)},
+		{"name": "Win condition", "value": string(r.Header.Teams[winningTeamIndex].WinCondition)},
+		{"name": "Opening kill", "value": openingKill.Username, "time": openingKill.Time},
+		{"name": "Opening death", "value": openingDeathUsername(r), "time": openingDeath.Time},
+	}
+	if r.Header.GameMode == Bomb {
+		var plant MatchUpdate
+		var defuse MatchUpdate
+		for _, update := range r.MatchFeedback {
+			if update.Type == DefuserPlantComplete {
+				plant = update
+			} else if update.Type == DefuserDisableComplete {
+				defuse = update
+			}
+		}
+		rows = append(rows,
+			excelRow{"name": "Planted at", "value": plant.Time},
+			excelRow{"name": "Defused at", "value": defuse.Time},
+		)
+	}
+	return rows
+}
+
+// openingDeathUsername returns the username of the first player to die.
+func openingDeathUsername(r *Reader) string {
+	openingDeath := r.OpeningDeath()
+	if openingDeath.Type == Kill {
+		return openingDeath.Target
+	}
+	return openingDeath.Username
+}
+
+// Validate returns an error if the layout references unknown sources or fields,
+// or names two sheets the same.
+func (l ExcelLayout) Validate() error {
+	if len(l.Sheets) == 0 {
+		return fmt.Errorf("dissect: excel layout has no sheets")
+	}
+	names := make(map[string]bool, len(l.Sheets))
+	for _, sheet := range l.Sheets {
+		if sheet.Scope != OverviewScope && sheet.Scope != MatchScope && sheet.Scope != RoundScope {
+			return fmt.Errorf("dissect: excel sheet %q: unknown scope %q", sheet.Name, sheet.Scope)
+		}
+		// sheet names are case-insensitive in Excel
+		name := strings.ToLower(sheet.Name)
+		if names[name] {
+			return fmt.Errorf("dissect: excel sheet %q: duplicate sheet name", sheet.Name)
+		}
+		names[name] = true
+		for _, table := range sheet.Tables {
+			if err := table.validate(); err != nil {
+				return fmt.Errorf("dissect: excel table %q: %w", table.Title, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (t ExcelTable) validate() error {
+	source, ok := excelSources[t.Source]
+	if !ok {
+		return fmt.Errorf("unknown source %q", t.Source)
+	}
+	field := func(name string) error {
+		if !slices.Contains(source.fields, name) {
+			return fmt.Errorf("unknown field %q of %s", name, t.Source)
+		}
+		return nil
+	}
+	if len(t.Position) > 0 {
+		if _, _, err := excelize.CellNameToCoordinates(t.Position); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{t.GroupBy, t.SortBy} {
+		if len(name) == 0 {
+			continue
+		}
+		if err := field(name); err != nil {
+			return err
+		}
+	}
+	columns := t.columns()
+	for _, column := range columns {
+		if err := field(column.Field); err != nil {
+			return err
+		}
+	}
+	for _, chart := range t.Charts {
+		if _, ok := excelChartTypes[chart.Type]; !ok {
+			return fmt.Errorf("unknown chart type %q", chart.Type)
+		}
+		if len(chart.Position) > 0 {
+			if _, _, err := excelize.CellNameToCoordinates(chart.Position); err != nil {
+				return err
+			}
+		}
+		for _, name := range append([]string{chart.Category}, chart.Values...) {
+			if !slices.ContainsFunc(columns, func(c ExcelColumn) bool { return c.Field == name }) {
+				return fmt.Errorf("chart %q: field %q is not a column", chart.Title, name)
+			}
+		}
+	}
+	return nil
+}
+
+// columns returns the columns of the table, or the default columns of its source.
+func (t ExcelTable) columns() []ExcelColumn {
+	if len(t.Columns) > 0 {
+		return t.Columns
+	}
+	return excelSources[t.Source].columns
+}
+
+func (m *MatchReader) WriteExcel(out io.Writer) error {
+	return m.WriteExcelLayout(out, DefaultExcelLayout)
+}
+
+// WriteExcelLayout writes a workbook with the match and round sheets of layout.
+// Overview sheets are skipped.
+func (m *MatchReader) WriteExcelLayout(out io.Writer, layout ExcelLayout) error {
+	return writeExcelLayout(out, layout, [][]*Reader{m.rounds}, matchWorkbook)
+}
+
+// WriteExcel writes a workbook with a single sheet for the round.
+func (r *Reader) WriteExcel(out io.Writer) error {
+	return r.WriteExcelLayout(out, DefaultExcelLayout)
+}
+
+// WriteExcelLayout writes a workbook with the round sheets of layout.
+// Overview and match sheets are skipped.
+func (r *Reader) WriteExcelLayout(out io.Writer, layout ExcelLayout) error {
+	return writeExcelLayout(out, layout, [][]*Reader{{r}}, roundWorkbook)
+}
+
+// WriteExcelMatches writes a workbook combining several matches.
+func WriteExcelMatches(out io.Writer, matches []*MatchReader) error {
+	return WriteExcelMatchesLayout(out, matches, DefaultExcelLayout)
+}
+
+// WriteExcelMatchesLayout writes the overview sheets of layout, followed by
+// the match and round sheets of each match prefixed with its number (e.g. "M2 Round 1").
+func WriteExcelMatchesLayout(out io.Writer, matches []*MatchReader, layout ExcelLayout) error {
+	rounds := make([][]*Reader, 0, len(matches))
+	for _, m := range matches {
+		rounds = append(rounds, m.rounds)
+	}
+	return writeExcelLayout(out, layout, rounds, matchesWorkbook)
+}
+
+type excelWorkbook int
+
+const (
+	roundWorkbook excelWorkbook = iota
+	matchWorkbook
+	matchesWorkbook
+)
+
+func writeExcelLayout(out io.Writer, layout ExcelLayout, matches [][]*Reader, workbook excelWorkbook) error {
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+	f := excelize.NewFile()
+	defer f.Close()
+
+	w := &excelWriter{c: newExcelCompass(f, "Sheet1"), highlights: make(map[string]int)}
+	if workbook == matchesWorkbook {
+		all := make([]*Reader, 0)
+		for _, rounds := range matches {
+			all = append(all, rounds...)
+		}
+		for _, sheet := range layout.Sheets {
+			if sheet.Scope != OverviewScope || len(all) == 0 {
+				continue
+			}
+			if err := w.sheet(sheet.Name, sheet, all); err != nil {
+				return err
+			}
+		}
+	}
+	for i, rounds := range matches {
+		prefix := ""
+		if workbook == matchesWorkbook {
+			prefix = fmt.Sprintf("M%d ", i+1)
+		}
+		for _, sheet := range layout.Sheets {
+			switch {
+			case sheet.Scope == RoundScope:
+				for j, r := range rounds {
+					number := j + 1
+					if workbook == roundWorkbook {
+						number = r.Header.RoundNumber + 1
+					}
+					name := prefix + strings.ReplaceAll(sheet.Name, "{round}", strconv.Itoa(number))
+					if err := w.sheet(name, sheet, []*Reader{r}); err != nil {
+						return err
+					}
+				}
+			case sheet.Scope == MatchScope && workbook != roundWorkbook && len(rounds) > 0:
+				if err := w.sheet(prefix+sheet.Name, sheet, rounds); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if w.sheets == 0 {
+		return fmt.Errorf("dissect: excel layout has no sheets for the workbook")
+	}
+	f.SetActiveSheet(0)
+
+	return f.Write(out)
+}
+
+type excelWriter struct {
+	c           *excelCompass
+	sheets      int
+	listObjects int
+	highlights  map[string]int // conditional styles by color
+}
+
+// sheet writes the tables of a sheet, reusing the default sheet for the first one.
+func (w *excelWriter) sheet(name string, sheet ExcelSheet, rounds []*Reader) error {
+	f := w.c.f
+	if w.sheets == 0 {
+		if err := f.SetSheetName("Sheet1", name); err != nil {
+			return err
+		}
+	} else if _, err := f.NewSheet(name); err != nil {
+		return err
+	}
+	w.sheets++
+	w.c.Sheet(name)
+	// row below the last table that was not positioned
+	next := 0
+	for _, table := range sheet.Tables {
+		w.c.Reset()
+		if len(table.Position) > 0 {
+			col, row, _ := excelize.CellNameToCoordinates(table.Position)
+			w.c.Down(row - 1).Right(col - 1)
+		} else {
+			w.c.Down(next)
+		}
+		rows := excelSources[table.Source].rows(rounds)
+		if len(table.SortBy) > 0 {
+			sortExcelRows(rows, table.SortBy, table.Descending)
+		}
+		if len(table.GroupBy) == 0 {
+			if err := w.table(table, table.Title, rows); err != nil {
+				return err
+			}
+		} else {
+			left := w.c.col
+			for i, group := range groupExcelRows(rows, table.GroupBy) {
+				if i > 0 {
+					w.c.Down(2).Left(w.c.col - left)
+				}
+				if err := w.table(table, fmt.Sprint(group[0][table.GroupBy]), group); err != nil {
+					return err
+				}
+			}
+		}
+		if len(table.Position) == 0 {
+			next = w.c.row + 2
+		}
+	}
+	if sheet.FreezeRows > 0 {
+		return f.SetPanes(name, &excelize.Panes{
+			Freeze:      true,
+			YSplit:      sheet.FreezeRows,
+			TopLeftCell: fmt.Sprintf("A%d", sheet.FreezeRows+1),
+			ActivePane:  "bottomLeft",
+		})
+	}
+	return nil
+}
+
+// table writes rows below a heading, leaving the compass on the last row.
+func (w *excelWriter) table(table ExcelTable, heading string, rows []excelRow) error {
+	c := w.c
+	columns := table.columns()
+	left := c.col
+	if len(heading) > 0 {
+		c.Heading(heading)
+	}
+	c.Down(1)
+	top := c.row
+	for i, column := range columns {
+		c.Right(min(i, 1)).Str(column.Header)
+	}
+	for _, row := range rows {
+		c.Down(1).Left(c.col - left)
+		for i, column := range columns {
+			c.Right(min(i, 1)).Value(row[column.Field])
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	cell := func(col, row int) string {
+		name, _ := excelize.CoordinatesToCellName(col+1, row+1, true)
+		return name
+	}
+	column := func(field string) int {
+		return left + slices.IndexFunc(columns, func(c ExcelColumn) bool { return c.Field == field })
+	}
+	if table.ListObject {
+		w.listObjects++
+		err := c.f.AddTable(c.s, &excelize.Table{
+			Range:     cell(left, top) + ":" + cell(left+len(columns)-1, c.row),
+			Name:      fmt.Sprintf("Table%d", w.listObjects),
+			StyleName: "TableStyleLight9",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for i, col := range columns {
+		for _, h := range col.Highlight {
+			style, ok := w.highlights[h.Color]
+			if !ok {
+				var err error
+				style, err = c.f.NewConditionalStyle(&excelize.Style{
+					Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{h.Color}},
+				})
+				if err != nil {
+					return err
+				}
+				w.highlights[h.Color] = style
+			}
+			err := c.f.SetConditionalFormat(c.s, cell(left+i, top+1)+":"+cell(left+i, c.row), []excelize.ConditionalFormatOptions{
+				{Type: "cell", Criteria: "==", Format: &style, Value: excelFormulaValue(h.Equals)},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	sheet := "'" + strings.ReplaceAll(c.s, "'", "''") + "'!"
+	for i, chart := range table.Charts {
+		position := chart.Position
+		if len(position) == 0 {
+			position, _ = excelize.CoordinatesToCellName(left+len(columns)+2, top+i*16)
+		}
+		categories := column(chart.Category)
+		series := make([]excelize.ChartSeries, 0, len(chart.Values))
+		for _, field := range chart.Values {
+			col := column(field)
+			series = append(series, excelize.ChartSeries{
+				Name:       sheet + cell(col, top),
+				Categories: sheet + cell(categories, top+1) + ":" + cell(categories, c.row),
+				Values:     sheet + cell(col, top+1) + ":" + cell(col, c.row),
+			})
+		}
+		err := c.f.AddChart(c.s, position, &excelize.Chart{
+			Type:   excelChartTypes[chart.Type],
+			Series: series,
+			Title:  []excelize.RichTextRun{{Text: chart.Title}},
+			Legend: excelize.ChartLegend{Position: "bottom"},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupExcelRows groups rows by the value of field, in order of first appearance.
+func groupExcelRows(rows []excelRow, field string) [][]excelRow {
+	groups := make([][]excelRow, 0)
+	index := make(map[any]int)
+	for _, row := range rows {
+		i, ok := index[row[field]]
+		if !ok {
+			i = len(groups)
+			index[row[field]] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], row)
+	}
+	return groups
+}
+
+// sortExcelRows sorts rows by the value of field, keeping the order of equal rows.
+// Rows without the field are placed last.
+func sortExcelRows(rows []excelRow, field string, descending bool) {
+	slices.SortStableFunc(rows, func(a, b excelRow) int {
+		x, y := a[field], b[field]
+		if x == nil || y == nil {
+			return cmp.Compare(excelRank(x == nil), excelRank(y == nil))
+		}
+		n := compareExcelValues(x, y)
+		if descending {
+			return -n
+		}
+		return n
+	})
+}
+
+func excelRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// compareExcelValues compares values of the same field.
+func compareExcelValues(a, b any) int {
+	switch a := a.(type) {
+	case int:
+		if b, ok := b.(int); ok {
+			return cmp.Compare(a, b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmp.Compare(a, b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return cmp.Compare(excelRank(a), excelRank(b))
+		}
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// excelFormulaValue returns v as a value in a conditional format formula.
+func excelFormulaValue(v any) string {
+	switch v := v.(type) {
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(v))
+	case string:
+		return `"` + strings.ReplaceAll(v, `"`, `""`) + `"`
+	}
+	return fmt.Sprint(v)
+}
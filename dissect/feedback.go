@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -46,6 +47,20 @@ func (i MatchUpdateType) MarshalJSON() (text []byte, err error) {
 }
 
 func (i *MatchUpdateType) UnmarshalJSON(data []byte) (err error) {
+	// older exports encoded the type as its name
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err = json.Unmarshal(data, &name); err != nil {
+			return
+		}
+		for t := Kill; t <= Other; t++ {
+			if t.String() == name {
+				*i = t
+				return
+			}
+		}
+		return fmt.Errorf("dissect: unknown match update type %q", name)
+	}
 	var x stringerIntMarshal
 	if err = json.Unmarshal(data, &x); err != nil {
 		return
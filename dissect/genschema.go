@@ -0,0 +1,67 @@
+// genschema writes the JSON Schemas of the round and match output to /schema
+// and records their hashes for the current SchemaVersion in schema/versions.json.
+// It fails when the output shape changed without a SchemaVersion bump.
+//
+//go:build ignore
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+const dir = "../schema"
+
+func main() {
+	round, err := dissect.RoundJSONSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	match, err := dissect.MatchJSONSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	versions := make(dissect.SchemaVersions)
+	b, err := os.ReadFile(filepath.Join(dir, "versions.json"))
+	if err == nil {
+		err = json.Unmarshal(b, &versions)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatal(err)
+	}
+	version := fmt.Sprint(dissect.SchemaVersion)
+	if _, ok := versions[version]; ok {
+		if err := dissect.CheckSchemaVersion(versions); err != nil {
+			log.Fatal(err)
+		}
+	}
+	v := versions[version]
+	v.Round = dissect.SchemaHash(round)
+	v.Match = dissect.SchemaHash(match)
+	versions[version] = v
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		log.Fatal(err)
+	}
+	b, err = json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	files := map[string][]byte{
+		"round.schema.json": round,
+		"match.schema.json": match,
+		"versions.json":     append(b, '\n'),
+	}
+	for name, b := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package dissect
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Index is a persistent catalog of match folders built from replay headers.
+type Index struct {
+	Matches []IndexEntry `json:"matches"`
+}
+
+type IndexEntry struct {
+	MatchID   string        `json:"matchID"`
+	Timestamp time.Time     `json:"timestamp"`
+	Map       Map           `json:"map"`
+	GameMode  GameMode      `json:"gamemode"`
+	MatchType MatchType     `json:"matchType"`
+	Teams     [2]IndexTeam  `json:"teams"`
+	Players   []IndexPlayer `json:"players"`
+	Path      string        `json:"path"`
+	Rounds    []string      `json:"rounds"`
+	Modified  time.Time     `json:"modified"`
+}
+
+type IndexTeam struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+}
+
+type IndexPlayer struct {
+	Username  string `json:"username"`
+	ProfileID string `json:"profileID,omitempty"`
+	TeamIndex int    `json:"teamIndex"`
+}
+
+// IndexQuery filters index entries. Empty fields match everything.
+type IndexQuery struct {
+	MatchType string    // case-insensitive MatchType name
+	GameMode  string    // case-insensitive GameMode name
+	Map       string    // case-insensitive Map name
+	Player    string    // case-insensitive username or profile id
+	Since     time.Time // inclusive
+	Until     time.Time // exclusive
+}
+
+func NewIndex() *Index {
+	return &Index{
+		Matches: make([]IndexEntry, 0),
+	}
+}
+
+// ReadIndex decodes an index previously written with WriteJSON.
+func ReadIndex(in io.Reader) (*Index, error) {
+	idx := NewIndex()
+	if err := json.NewDecoder(in).Decode(idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// OpenIndex reads the index at path, returning an empty index if the file does not exist.
+func OpenIndex(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewIndex(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadIndex(f)
+}
+
+func (idx *Index) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(idx)
+}
+
+// Save writes the index to path, replacing any existing file.
+func (idx *Index) Save(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = idx.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Scan walks root for match folders and indexes any folder that is new
+// or has changed since it was last indexed. Entries under root whose folder
+// no longer holds replays are removed. Folders are stored by absolute path,
+// so the same folder is indexed once however root is written. It returns
+// the number of folders that were (re)indexed.
+func (idx *Index) Scan(root string) (n int, err error) {
+	if root, err = filepath.Abs(root); err != nil {
+		return
+	}
+	// indexes written before paths were absolute
+	for i, entry := range idx.Matches {
+		if !filepath.IsAbs(entry.Path) {
+			if idx.Matches[i].Path, err = filepath.Abs(entry.Path); err != nil {
+				return
+			}
+		}
+	}
+	found := make(map[string]bool)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		indexed, err := idx.scanFolder(path)
+		if errors.Is(err, ErrInvalidFolder) {
+			return nil
+		}
+		// unreadable folders keep their previous entry
+		found[path] = true
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("skipping match folder")
+			return nil
+		}
+		if indexed {
+			n++
+		}
+		return nil
+	})
+	if err == nil {
+		idx.prune(root, found)
+		idx.sort()
+	}
+	return
+}
+
+// prune removes the entries under root that were not found by a scan of root.
+func (idx *Index) prune(root string, found map[string]bool) {
+	idx.Matches = slices.DeleteFunc(idx.Matches, func(entry IndexEntry) bool {
+		if found[entry.Path] || !withinDir(root, entry.Path) {
+			return false
+		}
+		log.Debug().Str("path", entry.Path).Str("matchID", entry.MatchID).Msg("removed missing match")
+		return true
+	})
+}
+
+func withinDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// scanFolder indexes the match folder at path if it changed.
+// It returns ErrInvalidFolder if path does not hold any replays.
+func (idx *Index) scanFolder(path string) (bool, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer dir.Close()
+	rounds, err := ListReplayFiles(dir)
+	if err != nil {
+		return false, err
+	}
+	modified, err := latestModTime(rounds)
+	if err != nil {
+		return false, err
+	}
+	existing := idx.indexOf(path)
+	if existing > -1 && slices.Equal(idx.Matches[existing].Rounds, rounds) &&
+		idx.Matches[existing].Modified.Equal(modified) {
+		return false, nil
+	}
+	entry, err := newIndexEntry(path, rounds)
+	if err != nil {
+		return false, err
+	}
+	entry.Modified = modified
+	if existing > -1 {
+		idx.Matches[existing] = entry
+	} else {
+		idx.Matches = append(idx.Matches, entry)
+	}
+	log.Debug().Str("path", path).Str("matchID", entry.MatchID).Msg("indexed match")
+	return true, nil
+}
+
+// newIndexEntry partially reads the last round of a match,
+// which carries the final score along with the player list.
+func newIndexEntry(path string, rounds []string) (entry IndexEntry, err error) {
+	f, err := os.Open(rounds[len(rounds)-1])
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	r, err := NewReader(f)
+	if err != nil {
+		return
+	}
+	if err = r.ReadPartial(); !Ok(err) {
+		return
+	}
+	entry = IndexEntry{
+		MatchID:   r.Header.MatchID,
+		Timestamp: r.Header.Timestamp,
+		Map:       r.Header.Map,
+		GameMode:  r.Header.GameMode,
+		MatchType: r.Header.MatchType,
+		Players:   make([]IndexPlayer, 0, len(r.Header.Players)),
+		Path:      path,
+		Rounds:    rounds,
+	}
+	for i, t := range r.Header.Teams {
+		entry.Teams[i] = IndexTeam{
+			Name:  t.Name,
+			Score: t.Score,
+		}
+	}
+	for _, p := range r.Header.Players {
+		entry.Players = append(entry.Players, IndexPlayer{
+			Username:  p.Username,
+			ProfileID: p.ProfileID,
+			TeamIndex: p.TeamIndex,
+		})
+	}
+	return entry, nil
+}
+
+func latestModTime(paths []string) (latest time.Time, err error) {
+	for _, p := range paths {
+		stat, err := os.Stat(p)
+		if err != nil {
+			return latest, err
+		}
+		if stat.ModTime().After(latest) {
+			latest = stat.ModTime()
+		}
+	}
+	return
+}
+
+func (idx *Index) indexOf(path string) int {
+	for i, entry := range idx.Matches {
+		if entry.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+func (idx *Index) sort() {
+	slices.SortStableFunc(idx.Matches, func(a, b IndexEntry) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+}
+
+// Query returns the entries matching every field set in q.
+func (idx *Index) Query(q IndexQuery) []IndexEntry {
+	entries := make([]IndexEntry, 0)
+	for _, entry := range idx.Matches {
+		if entry.Match(q) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (entry IndexEntry) Match(q IndexQuery) bool {
+	if q.MatchType != "" && !strings.EqualFold(entry.MatchType.String(), q.MatchType) {
+		return false
+	}
+	if q.GameMode != "" && !strings.EqualFold(entry.GameMode.String(), q.GameMode) {
+		return false
+	}
+	if q.Map != "" && !strings.EqualFold(entry.Map.String(), q.Map) {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !entry.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.Player == "" {
+		return true
+	}
+	for _, p := range entry.Players {
+		if strings.EqualFold(p.Username, q.Player) || strings.EqualFold(p.ProfileID, q.Player) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,16 +2,10 @@ package dissect
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
 	"os"
 	"path"
 	"slices"
 	"strings"
-
-	"github.com/rs/zerolog/log"
-	"github.com/xuri/excelize/v2"
 )
 
 type MatchReader struct {
@@ -19,8 +13,11 @@ type MatchReader struct {
 	paths  []string
 	rounds []*Reader
 
-	queries   [][]byte
-	listeners [][]func(r *Reader) error
+	tradeWindow       float64
+	ratingWeights     *RatingWeights
+	queries           [][]byte
+	listeners         [][]func(r *Reader) error
+	feedbackListeners []func(r *Reader, u MatchUpdate) error
 }
 
 func NewMatchReader(in *os.File) (m *MatchReader, err error) {
@@ -50,33 +47,55 @@ func (m *MatchReader) Listen(pattern []byte, callback func(r *Reader) error) {
 	m.listeners = append(m.listeners, []func(reader *Reader) error{callback})
 }
 
+// ListenFeedback registers a callback to be run during round Read
+// whenever a MatchUpdate is added to MatchFeedback.
+func (m *MatchReader) ListenFeedback(callback func(r *Reader, u MatchUpdate) error) {
+	m.feedbackListeners = append(m.feedbackListeners, callback)
+}
+
 func (m *MatchReader) read(i int) error {
-	if i < 0 || i >= len(m.paths) {
+	if i < 0 || i >= len(m.rounds) {
 		return ErrInvalidFile
 	}
 	if m.rounds[i] != nil {
 		return nil
 	}
-	f, err := os.Open(m.paths[i])
+	r, err := m.open(i)
 	if err != nil {
 		return err
 	}
+	return r.Read()
+}
+
+// open creates the Reader of round i with the match listeners and stats settings.
+func (m *MatchReader) open(i int) (*Reader, error) {
+	f, err := os.Open(m.paths[i])
+	if err != nil {
+		return nil, err
+	}
 	defer f.Close()
 	r, err := NewReader(f)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	m.rounds[i] = r
+	r.SetTradeWindow(m.tradeWindow)
+	if m.ratingWeights != nil {
+		r.SetRatingWeights(*m.ratingWeights)
+	}
 	for i = 0; i < len(m.queries); i++ {
 		for _, listener := range m.listeners[i] {
 			r.Listen(m.queries[i], listener)
 		}
 	}
-	return r.Read()
+	for _, listener := range m.feedbackListeners {
+		r.ListenFeedback(listener)
+	}
+	return r, nil
 }
 
 func (m *MatchReader) Read() error {
-	for i := range m.paths {
+	for i := range m.rounds {
 		if err := m.read(i); err != nil {
 			return err
 		}
@@ -93,8 +112,12 @@ func (m *MatchReader) LastRound() (r *Reader, err error) {
 }
 
 func (m *MatchReader) RoundAt(i int) (r *Reader, err error) {
+	if i < 0 || i >= len(m.rounds) {
+		return nil, ErrInvalidFile
+	}
 	if m.rounds[i] == nil {
-		if err := m.read(i); err != nil {
+		// the round is kept when reading stops at the end of the file
+		if err := m.read(i); !Ok(err) {
 			return nil, err
 		}
 	}
@@ -102,201 +125,7 @@ func (m *MatchReader) RoundAt(i int) (r *Reader, err error) {
 }
 
 func (m *MatchReader) NumRounds() int {
-	return len(m.paths)
-}
-
-func (m *MatchReader) WriteExcel(out io.Writer) error {
-	f := excelize.NewFile()
-	defer f.Close()
-
-	first, err := f.NewSheet("Match")
-	if err := f.DeleteSheet("Sheet1"); err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-
-	c := newExcelCompass(f, "Match")
-
-	for i, r := range m.rounds {
-		sheet := fmt.Sprintf("Round %d", i+1)
-		_, err := f.NewSheet(sheet)
-		if err != nil {
-			return err
-		}
-		c.Sheet(sheet)
-
-		// Conditional stats
-		openingKill := r.OpeningKill()
-		openingDeath := r.OpeningDeath()
-		openingDeathUsername := openingDeath.Username
-
-		if openingDeath.Type == Kill {
-			openingDeathUsername = openingDeath.Target
-		}
-
-		c.Heading("Statistics")
-		c.Down(1).Str("Player")
-		c.Right(1).Str("Team Index")
-		c.Right(1).Str("Kills")
-		c.Right(1).Str("Died")
-		c.Right(1).Str("Assists")
-		c.Right(1).Str("Hs%")
-		c.Right(1).Str("Headshots")
-		c.Right(1).Str("1vX")
-		c.Right(1).Str("Operator")
-
-		winningTeamIndex := 0
-		if r.Header.Teams[1].Won {
-			winningTeamIndex = 1
-		}
-
-		for _, s := range r.PlayerStats() {
-			c.Down(1).Left(8).Str(s.Username)
-			c.Right(1).Int(s.TeamIndex)
-			c.Right(1).Int(s.Kills)
-			c.Right(1).Bool(s.Died)
-			c.Right(1).Int(s.Assists)
-			c.Right(1).Float(s.HeadshotPercentage, 3)
-			c.Right(1).Int(s.Headshots)
-			c.Right(1).Int(s.OneVx)
-			c.Right(1).Str(s.Operator)
-			log.Debug().Interface("round_player_stats", s).Send()
-		}
-
-		c.Down(2).Left(8).Heading("Round info")
-		c.Down(1).Str("Name")
-		c.Right(1).Str("Value")
-		c.Right(1).Str("Time")
-		c.Down(1).Left(2).Str("Site")
-		c.Right(1).Str(r.Header.Site)
-		c.Down(1).Left(1).Str("Winning team")
-		c.Right(1).Str(fmt.Sprintf("%s [%d]", r.Header.Teams[winningTeamIndex].Name, winningTeamIndex))
-		c.Down(1).Left(2).Str("Win condition")
-		c.Right(1).Str(string(r.Header.Teams[winningTeamIndex].WinCondition))
-		c.Down(1).Left(2).Str("Opening kill")
-		c.Right(1).Str(openingKill.Username)
-		c.Right(1).Str(openingKill.Time)
-		c.Down(1).Left(3).Str("Opening death")
-		c.Right(1).Str(openingDeathUsername)
-		c.Right(1).Str(openingDeath.Time)
-
-		if r.Header.GameMode == Bomb {
-			var plant MatchUpdate
-			var defuse MatchUpdate
-			for _, update := range r.MatchFeedback {
-				if update.Type == DefuserPlantComplete {
-					plant = update
-				} else if update.Type == DefuserDisableComplete {
-					defuse = update
-				}
-			}
-			c.Down(1).Left(2).Str("Planted at")
-			if plant.Time != "" {
-				c.Right(1).Str(plant.Time)
-			} else {
-				c.Right(1).Str("")
-			}
-			c.Down(1).Left(2).Str("Defused at")
-			if defuse.Time != "" {
-				c.Right(1).Str(defuse.Time)
-			} else {
-				c.Right(1).Str("")
-			}
-		}
-
-		c.Down(2).Left(2).Heading("Kill/death feed")
-		c.Down(1).Str("Player")
-		c.Right(1).Str("Target")
-		c.Right(1).Str("Time")
-		c.Right(1).Str("Headshot")
-
-		for _, a := range r.KillsAndDeaths() {
-			c.Down(1).Left(3)
-			if a.Type == Kill {
-				c.Str(a.Username)
-				c.Right(1).Str(a.Target)
-			} else {
-				c.Str(a.Username)
-				c.Right(1).Str("")
-			}
-			c.Right(1).Str(a.Time)
-			headshot := false
-			if a.Type == Kill && *a.Headshot {
-				headshot = true
-			}
-			c.Right(1).Bool(headshot)
-		}
-
-		c.Reset().Right(10).Heading("Trades")
-		c.Down(1).Str("Player 1")
-		c.Right(1).Str("Player 2")
-		c.Right(1).Str("Time")
-		trades := r.Trades()
-
-		for _, trade := range trades {
-			c.Down(1).Left(2).Str(trade[0].Username)
-			c.Right(1).Str(trade[0].Target)
-			c.Right(1).Str(trade[1].Time)
-		}
-	}
-
-	c.Sheet("Match")
-	c.Heading("Statistics")
-	c.Down(1).Str("Player")
-	c.Right(1).Str("Team Index")
-	c.Right(1).Str("Rounds")
-	c.Right(1).Str("Kills")
-	c.Right(1).Str("Deaths")
-	c.Right(1).Str("Assists")
-	c.Right(1).Str("Hs%")
-	c.Right(1).Str("Headshots")
-
-	for _, s := range m.PlayerStats() {
-		c.Down(1).Left(8).Str(s.Username)
-		c.Right(1).Int(s.TeamIndex)
-		c.Right(1).Int(s.Rounds)
-		c.Right(1).Int(s.Kills)
-		c.Right(1).Int(s.Deaths)
-		c.Right(1).Int(s.Assists)
-		c.Right(1).Float(s.HeadshotPercentage, 3)
-		c.Right(1).Int(s.Headshots)
-		log.Debug().Interface("match_player_stats", s).Send()
-	}
-
-	f.SetActiveSheet(first)
-
-	return f.Write(out)
-}
-
-func (m *MatchReader) WriteJSON(out io.Writer) error {
-	encoder := json.NewEncoder(out)
-	return encoder.Encode(m.Data())
-}
-
-func (m *MatchReader) Data() any {
-	type round struct {
-		Header
-		MatchFeedback []MatchUpdate      `json:"matchFeedback"`
-		PlayerStats   []PlayerRoundStats `json:"stats"`
-	}
-	type output struct {
-		Rounds      []round            `json:"rounds"`
-		PlayerStats []PlayerMatchStats `json:"stats"`
-	}
-	rounds := make([]round, 0)
-	for _, r := range m.rounds {
-		rounds = append(rounds, round{
-			Header:        r.Header,
-			MatchFeedback: r.MatchFeedback,
-			PlayerStats:   r.PlayerStats(),
-		})
-	}
-	return output{
-		Rounds:      rounds,
-		PlayerStats: m.PlayerStats(),
-	}
+	return len(m.rounds)
 }
 
 func ListReplayFiles(root *os.File) ([]string, error) {
@@ -0,0 +1,136 @@
+package dissect
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type NDJSONLineType string
+
+const (
+	HeaderLine     NDJSONLineType = "header"
+	PlayerLine     NDJSONLineType = "player"
+	EventLine      NDJSONLineType = "event"
+	RoundStatsLine NDJSONLineType = "roundStats"
+	RoundEndLine   NDJSONLineType = "roundEnd"
+	MatchStatsLine NDJSONLineType = "matchStats"
+	MatchEndLine   NDJSONLineType = "matchEnd"
+)
+
+// NDJSONLine is a single line written by StreamRound and StreamMatch.
+// Only the field matching Type is set. Round is 1-based and omitted
+// from match lines. Header lines omit players, which follow as
+// player lines once the player list is complete.
+type NDJSONLine struct {
+	Type       NDJSONLineType    `json:"type"`
+	MatchID    string            `json:"matchID"`
+	Round      int               `json:"round,omitempty"`
+	Header     *Header           `json:"header,omitempty"`
+	Player     *Player           `json:"player,omitempty"`
+	Event      *MatchUpdate      `json:"event,omitempty"`
+	RoundStats *PlayerRoundStats `json:"roundStats,omitempty"`
+	MatchStats *PlayerMatchStats `json:"matchStats,omitempty"`
+	Teams      *[2]Team          `json:"teams,omitempty"`
+}
+
+// StreamRound reads r and writes NDJSON lines to w as the round is decoded.
+// A round that was already read is written at once.
+func StreamRound(r *Reader, w io.Writer) error {
+	return newNDJSONStream(w).round(r, r.b != nil)
+}
+
+// StreamMatch reads every round of m and writes NDJSON lines to w
+// as each round is decoded, followed by the match stats.
+// Rounds that were already read are written at once.
+func StreamMatch(m *MatchReader, w io.Writer) error {
+	s := newNDJSONStream(w)
+	for i := range m.rounds {
+		r := m.rounds[i]
+		read := r == nil
+		if read {
+			var err error
+			if r, err = m.open(i); err != nil {
+				return err
+			}
+		}
+		if err := s.round(r, read); err != nil {
+			return err
+		}
+	}
+	if len(m.rounds) == 0 {
+		return nil
+	}
+	last := m.rounds[len(m.rounds)-1].Header
+	for _, stats := range m.PlayerStats() {
+		err := s.write(NDJSONLine{Type: MatchStatsLine, MatchID: last.MatchID, MatchStats: &stats})
+		if err != nil {
+			return err
+		}
+	}
+	return s.write(NDJSONLine{Type: MatchEndLine, MatchID: last.MatchID, Teams: &last.Teams})
+}
+
+type ndjsonStream struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONStream(w io.Writer) *ndjsonStream {
+	return &ndjsonStream{json.NewEncoder(w)}
+}
+
+func (s *ndjsonStream) write(line NDJSONLine) error {
+	return s.encoder.Encode(line)
+}
+
+// round writes the lines of r, reading it first when read is true.
+func (s *ndjsonStream) round(r *Reader, read bool) error {
+	matchID := r.Header.MatchID
+	round := r.Header.RoundNumber + 1
+	header := r.Header
+	header.Players = nil
+	if err := s.write(NDJSONLine{Type: HeaderLine, MatchID: matchID, Round: round, Header: &header}); err != nil {
+		return err
+	}
+	playersWritten := false
+	writePlayers := func() error {
+		playersWritten = true
+		for _, p := range r.Header.Players {
+			if err := s.write(NDJSONLine{Type: PlayerLine, MatchID: matchID, Round: round, Player: &p}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// players are read before any match feedback
+	event := func(r *Reader, u MatchUpdate) error {
+		if !playersWritten {
+			if err := writePlayers(); err != nil {
+				return err
+			}
+		}
+		return s.write(NDJSONLine{Type: EventLine, MatchID: matchID, Round: round, Event: &u})
+	}
+	if read {
+		r.ListenFeedback(event)
+		if err := r.Read(); !Ok(err) {
+			return err
+		}
+	} else {
+		for _, u := range r.MatchFeedback {
+			if err := event(r, u); err != nil {
+				return err
+			}
+		}
+	}
+	if !playersWritten {
+		if err := writePlayers(); err != nil {
+			return err
+		}
+	}
+	for _, stats := range r.PlayerStats() {
+		if err := s.write(NDJSONLine{Type: RoundStatsLine, MatchID: matchID, Round: round, RoundStats: &stats}); err != nil {
+			return err
+		}
+	}
+	return s.write(NDJSONLine{Type: RoundEndLine, MatchID: matchID, Round: round, Teams: &r.Header.Teams})
+}
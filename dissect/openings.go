@@ -0,0 +1,120 @@
+package dissect
+
+// OpeningDuel is the first death of a round. Killer is empty when the
+// first player died without an opponent kill (e.g. fall damage or a team kill).
+type OpeningDuel struct {
+	Killer     string `json:"killer,omitempty"`
+	KillerTeam int    `json:"killerTeam"`
+	Victim     string `json:"victim"`
+	VictimTeam int    `json:"victimTeam"`
+	Time       string `json:"time"`
+}
+
+// OpeningDuelStats counts the opening duels a player took part in.
+type OpeningDuelStats struct {
+	Attempts    int     `json:"attempts"`
+	Wins        int     `json:"wins"`
+	Losses      int     `json:"losses"`
+	SuccessRate float64 `json:"successRate"` // percentage of attempts won
+}
+
+// PlayerOpeningDuels splits the opening duels of a player by side.
+type PlayerOpeningDuels struct {
+	Attack  OpeningDuelStats `json:"attack"`
+	Defense OpeningDuelStats `json:"defense"`
+	Total   OpeningDuelStats `json:"total"`
+}
+
+// TeamOpeningDuels relates the opening duels of a team to the rounds it won.
+type TeamOpeningDuels struct {
+	Name               string  `json:"name"`
+	Wins               int     `json:"wins"`
+	Losses             int     `json:"losses"`
+	WinRate            float64 `json:"winRate"` // percentage of opening duels won
+	RoundsWonAfterWin  int     `json:"roundsWonAfterWin"`
+	RoundsWonAfterLoss int     `json:"roundsWonAfterLoss"`
+	WinRateAfterWin    float64 `json:"winRateAfterWin"`  // percentage of rounds won after winning the opening duel
+	WinRateAfterLoss   float64 `json:"winRateAfterLoss"` // percentage of rounds won after losing the opening duel
+}
+
+// OpeningDuel returns the opening duel of the round, or false if nobody died.
+func (r *Reader) OpeningDuel() (OpeningDuel, bool) {
+	first := r.OpeningDeath()
+	duel := OpeningDuel{KillerTeam: -1, Time: first.Time}
+	switch first.Type {
+	case Kill:
+		duel.Victim = first.Target
+		duel.VictimTeam = r.teamIndex(first.Target)
+		if team := r.teamIndex(first.Username); team != duel.VictimTeam {
+			duel.Killer = first.Username
+			duel.KillerTeam = team
+		}
+	case Death:
+		duel.Victim = first.Username
+		duel.VictimTeam = r.teamIndex(first.Username)
+	default:
+		return duel, false
+	}
+	return duel, duel.VictimTeam >= 0
+}
+
+func (s *OpeningDuelStats) add(won bool) {
+	s.Attempts++
+	if won {
+		s.Wins++
+	} else {
+		s.Losses++
+	}
+	s.SuccessRate = percentage(s.Wins, s.Attempts)
+}
+
+func (d *PlayerOpeningDuels) add(role TeamRole, won bool) {
+	d.Total.add(won)
+	if role == Attack {
+		d.Attack.add(won)
+	} else if role == Defense {
+		d.Defense.add(won)
+	}
+}
+
+// TeamOpeningDuels returns the opening duel stats of both teams.
+func (m *MatchReader) TeamOpeningDuels() [2]TeamOpeningDuels {
+	teams := [2]TeamOpeningDuels{}
+	for _, r := range m.rounds {
+		teams[0].Name = r.Header.Teams[0].Name
+		teams[1].Name = r.Header.Teams[1].Name
+		duel, ok := r.OpeningDuel()
+		if !ok {
+			continue
+		}
+		for i := range teams {
+			t := &teams[i]
+			won := r.Header.Teams[i].Won
+			if duel.VictimTeam != i {
+				t.Wins++
+				if won {
+					t.RoundsWonAfterWin++
+				}
+			} else {
+				t.Losses++
+				if won {
+					t.RoundsWonAfterLoss++
+				}
+			}
+		}
+	}
+	for i := range teams {
+		t := &teams[i]
+		t.WinRate = percentage(t.Wins, t.Wins+t.Losses)
+		t.WinRateAfterWin = percentage(t.RoundsWonAfterWin, t.Wins)
+		t.WinRateAfterLoss = percentage(t.RoundsWonAfterLoss, t.Losses)
+	}
+	return teams
+}
+
+func percentage(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
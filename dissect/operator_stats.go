@@ -0,0 +1,126 @@
+package dissect
+
+import (
+	"cmp"
+	"slices"
+)
+
+// OperatorRecord is the performance of the rounds an operator was picked in.
+type OperatorRecord struct {
+	Picks           int     `json:"picks"`
+	RoundsWon       int     `json:"roundsWon"`
+	WinRate         float64 `json:"winRate"` // percentage of picks won
+	Kills           int     `json:"kills"`
+	Deaths          int     `json:"deaths"`
+	KD              float64 `json:"kd"`
+	SurvivalRate    float64 `json:"survivalRate"` // percentage of picks survived
+	OpeningKills    int     `json:"openingKills"`
+	OpeningKillRate float64 `json:"openingKillRate"` // percentage of picks with the opening kill
+}
+
+// OperatorStats are the stats of an operator on a side, with the split per player.
+type OperatorStats struct {
+	Operator Operator `json:"operator"`
+	Role     TeamRole `json:"role"`
+	OperatorRecord
+	Players []PlayerOperatorStats `json:"players"`
+}
+
+type PlayerOperatorStats struct {
+	Username  string `json:"username"`
+	ProfileID string `json:"profileID,omitempty"`
+	OperatorRecord
+}
+
+// OperatorStats returns the stats of every operator picked in the match,
+// ordered by side and picks.
+func (m *MatchReader) OperatorStats() []OperatorStats {
+	return operatorStats(m.rounds)
+}
+
+// OperatorStatsOf returns the operator stats of several matches combined.
+// Players are grouped by profile ID.
+func OperatorStatsOf(matches ...*MatchReader) []OperatorStats {
+	rounds := make([]*Reader, 0)
+	for _, m := range matches {
+		rounds = append(rounds, m.rounds...)
+	}
+	return operatorStats(rounds)
+}
+
+func operatorStats(rounds []*Reader) []OperatorStats {
+	type key struct {
+		operator Operator
+		role     TeamRole
+	}
+	stats := make([]OperatorStats, 0)
+	index := make(map[key]int)
+	players := make([]map[string]int, 0)
+	for _, r := range rounds {
+		for i, s := range r.PlayerStats() {
+			p := r.Header.Players[i]
+			if p.Operator == 0 {
+				continue
+			}
+			// recruits are picked on both sides
+			role := r.Header.Teams[p.TeamIndex].Role
+			if p.Operator != Recruit {
+				role = p.Operator.Role()
+			}
+			k := key{p.Operator, role}
+			j, ok := index[k]
+			if !ok {
+				j = len(stats)
+				index[k] = j
+				stats = append(stats, OperatorStats{Operator: p.Operator, Role: role, Players: make([]PlayerOperatorStats, 0)})
+				players = append(players, make(map[string]int))
+			}
+			won := r.Header.Teams[p.TeamIndex].Won
+			stats[j].add(s, won)
+			id := playerKey(p.ProfileID, p.Username)
+			l, ok := players[j][id]
+			if !ok {
+				l = len(stats[j].Players)
+				players[j][id] = l
+				stats[j].Players = append(stats[j].Players, PlayerOperatorStats{ProfileID: p.ProfileID})
+			}
+			stats[j].Players[l].Username = p.Username
+			stats[j].Players[l].add(s, won)
+		}
+	}
+	for i := range stats {
+		slices.SortStableFunc(stats[i].Players, func(a, b PlayerOperatorStats) int {
+			return cmp.Compare(b.Picks, a.Picks)
+		})
+	}
+	slices.SortStableFunc(stats, func(a, b OperatorStats) int {
+		return cmp.Or(cmp.Compare(a.Role, b.Role), cmp.Compare(b.Picks, a.Picks), cmp.Compare(a.Operator.String(), b.Operator.String()))
+	})
+	return stats
+}
+
+func (o *OperatorRecord) add(s PlayerRoundStats, won bool) {
+	o.Picks++
+	if won {
+		o.RoundsWon++
+	}
+	o.Kills += s.Kills
+	if s.Died {
+		o.Deaths++
+	}
+	if s.OpeningKill {
+		o.OpeningKills++
+	}
+	o.WinRate = percentage(o.RoundsWon, o.Picks)
+	o.KD = kd(o.Kills, o.Deaths)
+	o.SurvivalRate = percentage(o.Picks-o.Deaths, o.Picks)
+	o.OpeningKillRate = percentage(o.OpeningKills, o.Picks)
+}
+
+// kd returns kills per death, or the kills if there are no deaths.
+func kd(kills, deaths int) float64 {
+	if deaths == 0 {
+		return float64(kills)
+	}
+	return float64(kills) / float64(deaths)
+}
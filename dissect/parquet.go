@@ -0,0 +1,261 @@
+package dissect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Parquet table names. Each table is a directory of files
+// written by ParquetWriter.
+const (
+	EventsParquet           = "events"
+	PlayerRoundStatsParquet = "player_round_stats"
+)
+
+// ParquetPartition defines how Parquet files are laid out on disk.
+type ParquetPartition int
+
+const (
+	// PartitionByMatch writes <table>/match=<match id>/data.parquet.
+	PartitionByMatch ParquetPartition = iota
+	// PartitionByDay writes <table>/date=<YYYY-MM-DD>/<match id>.parquet.
+	PartitionByDay
+)
+
+// ParquetMatch holds the match columns shared by every Parquet row.
+// Enums are stored as both name and ID like the JSON output.
+type ParquetMatch struct {
+	MatchID     string    `parquet:"match_id,dict"`
+	Timestamp   time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	GameVersion string    `parquet:"game_version,dict"`
+	MatchType   string    `parquet:"match_type,dict"`
+	MatchTypeID int64     `parquet:"match_type_id"`
+	GameMode    string    `parquet:"game_mode,dict"`
+	GameModeID  int64     `parquet:"game_mode_id"`
+	Map         string    `parquet:"map,dict"`
+	MapID       int64     `parquet:"map_id"`
+}
+
+// ParquetEvent is a row of the events table, one per match feedback event.
+// Round is 1-based.
+type ParquetEvent struct {
+	ParquetMatch
+	Round           int32   `parquet:"round"`
+	EventIndex      int32   `parquet:"event_index"`
+	Type            string  `parquet:"type,dict"`
+	TypeID          int64   `parquet:"type_id"`
+	Username        string  `parquet:"username,dict"`
+	ProfileID       string  `parquet:"profile_id,dict"`
+	Target          string  `parquet:"target,dict"`
+	TargetProfileID string  `parquet:"target_profile_id,dict"`
+	Headshot        *bool   `parquet:"headshot,optional"`
+	Time            string  `parquet:"time"`
+	TimeInSeconds   float64 `parquet:"time_in_seconds"`
+	Message         string  `parquet:"message"`
+	Operator        string  `parquet:"operator,dict"`
+	OperatorID      int64   `parquet:"operator_id"`
+}
+
+// ParquetPlayerRound is a row of the player_round_stats table,
+// one per player and round. Round is 1-based.
+type ParquetPlayerRound struct {
+	ParquetMatch
+	Round              int32   `parquet:"round"`
+	Site               string  `parquet:"site,dict"`
+	ProfileID          string  `parquet:"profile_id,dict"`
+	Username           string  `parquet:"username,dict"`
+	TeamIndex          int32   `parquet:"team_index"`
+	TeamRole           string  `parquet:"team_role,dict"`
+	Won                bool    `parquet:"won"`
+	Operator           string  `parquet:"operator,dict"`
+	OperatorID         int64   `parquet:"operator_id"`
+	Score              int32   `parquet:"score"`
+	Kills              int32   `parquet:"kills"`
+	Died               bool    `parquet:"died"`
+	Assists            int32   `parquet:"assists"`
+	Headshots          int32   `parquet:"headshots"`
+	HeadshotPercentage float64 `parquet:"headshot_percentage"`
+	OneVx              int32   `parquet:"one_vx"`
+}
+
+// ParquetWriter writes events and player round stats to partitioned
+// Parquet files, one file per match and table. A match is written as soon
+// as it is complete: at the end of WriteMatch, or when WriteRound is given
+// a round of another match. Files of matches written before are replaced.
+type ParquetWriter struct {
+	dir       string
+	partition ParquetPartition
+	matchID   string // match of the buffered rows
+	date      string
+	events    []ParquetEvent
+	players   []ParquetPlayerRound
+	written   map[string]bool // matches this writer has written files for
+}
+
+func NewParquetWriter(dir string, partition ParquetPartition) *ParquetWriter {
+	return &ParquetWriter{
+		dir:       dir,
+		partition: partition,
+		written:   make(map[string]bool),
+	}
+}
+
+// WriteMatch writes the rows of every round in the match.
+func (w *ParquetWriter) WriteMatch(m *MatchReader) error {
+	for _, r := range m.rounds {
+		if err := w.WriteRound(r); err != nil {
+			return err
+		}
+	}
+	return w.flush()
+}
+
+// WriteRound buffers the rows of a single round until its match is complete.
+func (w *ParquetWriter) WriteRound(r *Reader) error {
+	h := r.Header
+	if h.MatchID != w.matchID {
+		if err := w.flush(); err != nil {
+			return err
+		}
+		w.matchID = h.MatchID
+		w.date = h.Timestamp.UTC().Format(time.DateOnly)
+	}
+	w.events = append(w.events, r.ParquetEvents()...)
+	w.players = append(w.players, r.ParquetPlayerRounds()...)
+	return nil
+}
+
+// Close writes the rows of the last match.
+func (w *ParquetWriter) Close() error {
+	return w.flush()
+}
+
+// flush writes the buffered rows of the current match. Rows of a match
+// written earlier by this writer, e.g. rounds given out of order, are kept.
+func (w *ParquetWriter) flush() error {
+	if len(w.events) == 0 && len(w.players) == 0 {
+		return nil
+	}
+	events := w.path(EventsParquet)
+	players := w.path(PlayerRoundStatsParquet)
+	if w.written[w.matchID] {
+		previousEvents, err := parquet.ReadFile[ParquetEvent](events)
+		if err != nil {
+			return fmt.Errorf("parquet: %s: %w", events, err)
+		}
+		previousPlayers, err := parquet.ReadFile[ParquetPlayerRound](players)
+		if err != nil {
+			return fmt.Errorf("parquet: %s: %w", players, err)
+		}
+		w.events = append(previousEvents, w.events...)
+		w.players = append(previousPlayers, w.players...)
+	}
+	if err := writeParquetFile(events, w.events); err != nil {
+		return err
+	}
+	if err := writeParquetFile(players, w.players); err != nil {
+		return err
+	}
+	w.written[w.matchID] = true
+	w.events = nil
+	w.players = nil
+	return nil
+}
+
+func (w *ParquetWriter) path(table string) string {
+	if w.partition == PartitionByDay {
+		return filepath.Join(w.dir, table, "date="+w.date, w.matchID+".parquet")
+	}
+	return filepath.Join(w.dir, table, "match="+w.matchID, "data.parquet")
+}
+
+func writeParquetFile[T any](path string, rows []T) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	// written next to the destination first so readers never see a partial file
+	if err := parquet.WriteFile(path+".tmp", rows); err != nil {
+		return fmt.Errorf("parquet: %s: %w", path, err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (h Header) parquetMatch() ParquetMatch {
+	return ParquetMatch{
+		MatchID:     h.MatchID,
+		Timestamp:   h.Timestamp,
+		GameVersion: h.GameVersion,
+		MatchType:   h.MatchType.String(),
+		MatchTypeID: int64(h.MatchType),
+		GameMode:    h.GameMode.String(),
+		GameModeID:  int64(h.GameMode),
+		Map:         h.Map.String(),
+		MapID:       int64(h.Map),
+	}
+}
+
+// ParquetEvents returns the match feedback as rows of the events table.
+func (r *Reader) ParquetEvents() []ParquetEvent {
+	match := r.Header.parquetMatch()
+	rows := make([]ParquetEvent, 0, len(r.MatchFeedback))
+	for i, u := range r.MatchFeedback {
+		row := ParquetEvent{
+			ParquetMatch:    match,
+			Round:           int32(r.Header.RoundNumber + 1),
+			EventIndex:      int32(i),
+			Type:            u.Type.String(),
+			TypeID:          int64(u.Type),
+			Username:        u.Username,
+			ProfileID:       r.profileID(u.Username),
+			Target:          u.Target,
+			TargetProfileID: r.profileID(u.Target),
+			Headshot:        u.Headshot,
+			Time:            u.Time,
+			TimeInSeconds:   u.TimeInSeconds,
+			Message:         u.Message,
+		}
+		if u.Operator != 0 {
+			row.Operator = u.Operator.String()
+			row.OperatorID = int64(u.Operator)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ParquetPlayerRounds returns the player stats as rows of the
+// player_round_stats table.
+func (r *Reader) ParquetPlayerRounds() []ParquetPlayerRound {
+	h := r.Header
+	match := h.parquetMatch()
+	stats := r.PlayerStats()
+	rows := make([]ParquetPlayerRound, 0, len(stats))
+	for i, s := range stats {
+		operator := h.Players[i].Operator
+		team := h.Teams[s.TeamIndex]
+		rows = append(rows, ParquetPlayerRound{
+			ParquetMatch:       match,
+			Round:              int32(h.RoundNumber + 1),
+			Site:               h.Site,
+			ProfileID:          s.ProfileID,
+			Username:           s.Username,
+			TeamIndex:          int32(s.TeamIndex),
+			TeamRole:           string(team.Role),
+			Won:                team.Won,
+			Operator:           operator.String(),
+			OperatorID:         int64(operator),
+			Score:              int32(s.Score),
+			Kills:              int32(s.Kills),
+			Died:               s.Died,
+			Assists:            int32(s.Assists),
+			Headshots:          int32(s.Headshots),
+			HeadshotPercentage: s.HeadshotPercentage,
+			OneVx:              int32(s.OneVx),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,93 @@
+package dissect
+
+// RatingWeights weigh the contributions to the rating of a player in a round:
+//
+//	rating = Base
+//	       + Kill × kills + Assist × assists + Death × (1 if died)
+//	       + KOST × (1 if KOST) + Survival × (1 if survived)
+//	       + OpeningKill × (1 if won the opening duel)
+//	       + OpeningDeath × (1 if lost the opening duel)
+//	       + Clutch × opponents of a won clutch
+//	       + Plant × (1 if planted) + Defuse × (1 if defused)
+//
+// The rating of a match is the average rating of its rounds.
+// Negative weights are penalties.
+type RatingWeights struct {
+	Base         float64 `json:"base" mapstructure:"base"`
+	Kill         float64 `json:"kill" mapstructure:"kill"`
+	Assist       float64 `json:"assist" mapstructure:"assist"`
+	Death        float64 `json:"death" mapstructure:"death"`
+	KOST         float64 `json:"kost" mapstructure:"kost"`
+	Survival     float64 `json:"survival" mapstructure:"survival"`
+	OpeningKill  float64 `json:"openingKill" mapstructure:"openingKill"`
+	OpeningDeath float64 `json:"openingDeath" mapstructure:"openingDeath"`
+	Clutch       float64 `json:"clutch" mapstructure:"clutch"` // per opponent of a won clutch
+	Plant        float64 `json:"plant" mapstructure:"plant"`
+	Defuse       float64 `json:"defuse" mapstructure:"defuse"`
+}
+
+// DefaultRatingWeights are the weights of the ratings in PlayerRoundStats and
+// PlayerMatchStats unless set with SetRatingWeights. They put an average round
+// (0.7 kills, 0.7 deaths, 0.3 assists and 70% KOST) at about 1.0.
+var DefaultRatingWeights = RatingWeights{
+	Base:         0.55,
+	Kill:         0.5,
+	Assist:       0.15,
+	Death:        -0.35,
+	KOST:         0.3,
+	Survival:     0.2,
+	OpeningKill:  0.2,
+	OpeningDeath: -0.15,
+	Clutch:       0.25,
+	Plant:        0.15,
+	Defuse:       0.25,
+}
+
+// Round returns the rating of a player in a round.
+func (w RatingWeights) Round(s PlayerRoundStats) float64 {
+	rating := w.Base +
+		w.Kill*float64(s.Kills) +
+		w.Assist*float64(s.Assists) +
+		w.Clutch*float64(s.OneVx)
+	flags := []struct {
+		set    bool
+		weight float64
+	}{
+		{s.Died, w.Death},
+		{!s.Died, w.Survival},
+		{s.KOST, w.KOST},
+		{s.OpeningKill, w.OpeningKill},
+		{s.OpeningDeath, w.OpeningDeath},
+		{s.Planted, w.Plant},
+		{s.Defused, w.Defuse},
+	}
+	for _, f := range flags {
+		if f.set {
+			rating += f.weight
+		}
+	}
+	return rating
+}
+
+// SetRatingWeights sets the weights of the player ratings of the round.
+func (r *Reader) SetRatingWeights(w RatingWeights) {
+	r.ratingWeights = &w
+}
+
+// RatingWeights returns the weights of the player ratings of the round.
+func (r *Reader) RatingWeights() RatingWeights {
+	if r.ratingWeights == nil {
+		return DefaultRatingWeights
+	}
+	return *r.ratingWeights
+}
+
+// SetRatingWeights sets the rating weights of every round, see Reader.SetRatingWeights.
+func (m *MatchReader) SetRatingWeights(w RatingWeights) {
+	m.ratingWeights = &w
+	for _, r := range m.rounds {
+		if r != nil {
+			r.SetRatingWeights(w)
+		}
+	}
+}
@@ -22,6 +22,8 @@ type Reader struct {
 	offset                   int
 	queries                  [][]byte
 	listeners                [][]func(r *Reader) error
+	feedbackListeners        []func(r *Reader, u MatchUpdate) error
+	feedbackNotified         int
 	time                     float64 // in seconds
 	timeRaw                  string  // raw dissect format
 	lastDefuserPlayerIndex   int
@@ -29,8 +31,10 @@ type Reader struct {
 	readPartial              bool // reads up to the player info packets
 	playersRead              int
 	lastKillerFromScoreboard string
-	Header                   Header        `json:"header"`
-	MatchFeedback            []MatchUpdate `json:"matchFeedback"`
+	tradeWindow              float64        // in seconds, see SetTradeWindow
+	ratingWeights            *RatingWeights // see SetRatingWeights
+	Header                   Header         `json:"header"`
+	MatchFeedback            []MatchUpdate  `json:"matchFeedback"`
 	Scoreboard               Scoreboard
 }
 
@@ -223,6 +227,9 @@ func (r *Reader) Read() (err error) {
 			if err = listener(r); err != nil {
 				return
 			}
+			if err = r.notifyFeedback(); err != nil {
+				return
+			}
 		}
 	}
 	if !r.readPartial {
@@ -257,6 +264,23 @@ func (r *Reader) Listen(pattern []byte, callback func(r *Reader) error) {
 	r.listeners = append(r.listeners, []func(reader *Reader) error{callback})
 }
 
+// ListenFeedback registers a callback to be run during Read whenever
+// a MatchUpdate is added to MatchFeedback.
+func (r *Reader) ListenFeedback(callback func(r *Reader, u MatchUpdate) error) {
+	r.feedbackListeners = append(r.feedbackListeners, callback)
+}
+
+func (r *Reader) notifyFeedback() error {
+	for ; r.feedbackNotified < len(r.MatchFeedback); r.feedbackNotified++ {
+		for _, listener := range r.feedbackListeners {
+			if err := listener(r, r.MatchFeedback[r.feedbackNotified]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Seek skips through the replay until the pattern is found.
 func (r *Reader) Seek(pattern []byte) error {
 	start := r.offset
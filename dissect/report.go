@@ -0,0 +1,197 @@
+package dissect
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type ReportFormat string
+
+const (
+	HTMLReport     ReportFormat = "html"
+	MarkdownReport ReportFormat = "md"
+)
+
+//go:embed templates/report.html.tmpl templates/report.md.tmpl
+var reportTemplates embed.FS
+
+// Report is the data passed to report templates.
+type Report struct {
+	MatchID     string
+	Timestamp   time.Time
+	GameVersion string
+	MatchType   MatchType
+	GameMode    GameMode
+	Map         Map
+	Teams       [2]Team // final score
+	Rounds      []ReportRound
+	Players     []PlayerMatchStats
+}
+
+// ReportRound summarizes a round. Number is 1-based.
+type ReportRound struct {
+	Number       int
+	Site         string
+	Teams        [2]Team // score after the round
+	Winner       int     // team index, -1 if unknown
+	WinCondition WinCondition
+	Kills        []ReportKill
+	OpeningDuel  *ReportKill
+	Trades       [][2]ReportKill
+	Objectives   []MatchUpdate // plants and defuses
+	Picks        []ReportPick
+}
+
+type ReportKill struct {
+	Time       string
+	Killer     string
+	KillerTeam int
+	Target     string
+	TargetTeam int
+	Headshot   bool
+}
+
+type ReportPick struct {
+	Username  string
+	TeamIndex int
+	Role      TeamRole
+	Operator  Operator
+}
+
+// Report returns the report data of the match.
+func (m *MatchReader) Report() Report {
+	report := Report{Players: m.PlayerStats()}
+	for _, r := range m.rounds {
+		report.Rounds = append(report.Rounds, r.reportRound())
+	}
+	if len(m.rounds) > 0 {
+		h := m.rounds[len(m.rounds)-1].Header
+		report.MatchID = h.MatchID
+		report.Timestamp = h.Timestamp
+		report.GameVersion = h.GameVersion
+		report.MatchType = h.MatchType
+		report.GameMode = h.GameMode
+		report.Map = h.Map
+		report.Teams = h.Teams
+	}
+	return report
+}
+
+// Report returns the report data of a single round.
+func (r *Reader) Report() Report {
+	m := &MatchReader{rounds: []*Reader{r}}
+	return m.Report()
+}
+
+func (r *Reader) reportRound() ReportRound {
+	h := r.Header
+	round := ReportRound{
+		Number: h.RoundNumber + 1,
+		Site:   h.Site,
+		Teams:  h.Teams,
+		Winner: -1,
+	}
+	for i, t := range h.Teams {
+		if t.Won {
+			round.Winner = i
+			round.WinCondition = t.WinCondition
+		}
+	}
+	for _, u := range r.MatchFeedback {
+		switch u.Type {
+		case Kill:
+			round.Kills = append(round.Kills, r.reportKill(u))
+		case DefuserPlantComplete, DefuserDisableComplete:
+			round.Objectives = append(round.Objectives, u)
+		}
+	}
+	if duel, ok := r.OpeningDuel(); ok {
+		round.OpeningDuel = &ReportKill{
+			Time:       duel.Time,
+			Killer:     duel.Killer,
+			KillerTeam: duel.KillerTeam,
+			Target:     duel.Victim,
+			TargetTeam: duel.VictimTeam,
+		}
+	}
+	for _, trade := range r.TradesWithin(r.TradeWindow()) {
+		round.Trades = append(round.Trades, [2]ReportKill{r.reportKill(trade.Kill), r.reportKill(trade.Refrag)})
+	}
+	for _, p := range h.Players {
+		round.Picks = append(round.Picks, ReportPick{
+			Username:  p.Username,
+			TeamIndex: p.TeamIndex,
+			Role:      h.Teams[p.TeamIndex].Role,
+			Operator:  p.Operator,
+		})
+	}
+	return round
+}
+
+func (r *Reader) reportKill(u MatchUpdate) ReportKill {
+	return ReportKill{
+		Time:       u.Time,
+		Killer:     u.Username,
+		KillerTeam: r.teamIndex(u.Username),
+		Target:     u.Target,
+		TargetTeam: r.teamIndex(u.Target),
+		Headshot:   u.Headshot != nil && *u.Headshot,
+	}
+}
+
+func (r *Reader) teamIndex(username string) int {
+	for _, p := range r.Header.Players {
+		if p.Username == username {
+			return p.TeamIndex
+		}
+	}
+	return -1
+}
+
+// WriteReport writes the report using the built-in template of format.
+func (report Report) WriteReport(out io.Writer, format ReportFormat) error {
+	b, err := reportTemplates.ReadFile(fmt.Sprintf("templates/report.%s.tmpl", format))
+	if err != nil {
+		return fmt.Errorf("dissect: unknown report format %q", format)
+	}
+	return report.WriteTemplate(out, format, string(b))
+}
+
+// WriteTemplate writes the report using a user-supplied template in
+// text/template syntax. HTML templates are parsed with html/template
+// so player names are escaped.
+func (report Report) WriteTemplate(out io.Writer, format ReportFormat, text string) error {
+	if format == HTMLReport {
+		t, err := htmltemplate.New("report").Funcs(reportFuncs).Parse(text)
+		if err != nil {
+			return err
+		}
+		return t.Execute(out, report)
+	}
+	t, err := template.New("report").Funcs(reportFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(out, report)
+}
+
+var reportFuncs = map[string]any{
+	// md escapes characters with a meaning in Markdown tables and text
+	"md": strings.NewReplacer(
+		`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", ">", "&gt;",
+	).Replace,
+	"percent": func(n float64) string {
+		return fmt.Sprintf("%.0f%%", n)
+	},
+	"kd": func(kills, deaths int) string {
+		if deaths == 0 {
+			return fmt.Sprintf("%.2f", float64(kills))
+		}
+		return fmt.Sprintf("%.2f", float64(kills)/float64(deaths))
+	},
+}
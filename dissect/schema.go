@@ -0,0 +1,179 @@
+package dissect
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const schemaBaseURL = "https://raw.githubusercontent.com/redraskal/r6-dissect/main/schema/"
+
+// RoundJSONSchema returns the JSON Schema of RoundData.
+func RoundJSONSchema() ([]byte, error) {
+	return jsonSchema(RoundData{}, "round.schema.json")
+}
+
+// MatchJSONSchema returns the JSON Schema of MatchData.
+func MatchJSONSchema() ([]byte, error) {
+	return jsonSchema(MatchData{}, "match.schema.json")
+}
+
+// SchemaHash returns the hash recorded for a schema in schema/versions.json.
+func SchemaHash(schema []byte) string {
+	sum := sha256.Sum256(schema)
+	return hex.EncodeToString(sum[:])
+}
+
+func jsonSchema(v any, name string) ([]byte, error) {
+	g := schemaGenerator{defs: make(map[string]any)}
+	t := reflect.TypeOf(v)
+	root := g.object(t)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = schemaBaseURL + name
+	root["title"] = t.Name()
+	root["$defs"] = g.defs
+	b, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+var (
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t.Implements(marshalerType) {
+			// enums are encoded with stringerIntMarshal
+			return g.ref(t, func() map[string]any {
+				return map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"name": map[string]any{"type": "string"},
+						"id":   map[string]any{"type": "integer"},
+					},
+					"required": []string{"name", "id"},
+				}
+			})
+		}
+		if t.Kind() >= reflect.Uint {
+			return map[string]any{"type": "integer", "minimum": 0}
+		}
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Slice:
+		// nil slices are encoded as null
+		return map[string]any{"type": []string{"array", "null"}, "items": g.schema(t.Elem())}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem()), "minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.ref(t, func() map[string]any {
+			return g.object(t)
+		})
+	}
+	return map[string]any{}
+}
+
+// ref adds the schema of a named type to $defs and references it.
+func (g *schemaGenerator) ref(t reflect.Type, schema func() map[string]any) map[string]any {
+	if _, ok := g.defs[t.Name()]; !ok {
+		g.defs[t.Name()] = nil // placeholder for recursive types
+		g.defs[t.Name()] = schema()
+	}
+	return map[string]any{"$ref": "#/$defs/" + t.Name()}
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := make([]string, 0)
+	g.fields(t, properties, &required)
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// fields adds the JSON fields of t, including embedded structs,
+// the same way encoding/json encodes them.
+func (g *schemaGenerator) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && len(name) == 0 && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		schema := g.schema(f.Type)
+		if f.Name == "SchemaVersion" {
+			schema = map[string]any{"type": "integer", "const": SchemaVersion}
+		}
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// SchemaVersions is the format of schema/versions.json, which records the
+// schema hashes of each released SchemaVersion.
+type SchemaVersions map[string]struct {
+	Round string `json:"round"`
+	Match string `json:"match"`
+}
+
+// CheckSchemaVersion returns an error if the schemas of the current
+// SchemaVersion in versions differ from the generated schemas.
+func CheckSchemaVersion(versions SchemaVersions) error {
+	round, err := RoundJSONSchema()
+	if err != nil {
+		return err
+	}
+	match, err := MatchJSONSchema()
+	if err != nil {
+		return err
+	}
+	v, ok := versions[fmt.Sprint(SchemaVersion)]
+	if !ok {
+		return fmt.Errorf("schema version %d is not recorded, run go generate ./dissect", SchemaVersion)
+	}
+	if v.Round != SchemaHash(round) || v.Match != SchemaHash(match) {
+		return fmt.Errorf("output shape changed without a schema version bump, increase SchemaVersion (%d) and run go generate ./dissect", SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package dissect
+
+import (
+	"cmp"
+	"slices"
+)
+
+// SiteStats are the outcomes of the Bomb rounds defended on a site of a map.
+type SiteStats struct {
+	Map              Map          `json:"map"`
+	Site             string       `json:"site"`
+	Rounds           int          `json:"rounds"`
+	PickRate         float64      `json:"pickRate"` // percentage of the rounds on the map defended on the site
+	AttackWins       int          `json:"attackWins"`
+	DefenseWins      int          `json:"defenseWins"`
+	AttackWinRate    float64      `json:"attackWinRate"`
+	Plants           int          `json:"plants"`
+	PlantRate        float64      `json:"plantRate"` // percentage of rounds with a completed plant
+	PostPlantWins    int          `json:"postPlantWins"`
+	PostPlantWinRate float64      `json:"postPlantWinRate"` // percentage of plants won by the attackers
+	Spawns           []SpawnStats `json:"spawns"`
+}
+
+// SpawnStats relates an attack spawn to the rounds won by the attackers
+// who picked it. Picks count players, not rounds.
+type SpawnStats struct {
+	Spawn     string  `json:"spawn"`
+	Picks     int     `json:"picks"`
+	RoundsWon int     `json:"roundsWon"`
+	WinRate   float64 `json:"winRate"`
+}
+
+// SiteStats returns the stats of the sites defended in the match,
+// ordered by map and rounds. Rounds of other game modes than Bomb are ignored.
+func (m *MatchReader) SiteStats() []SiteStats {
+	return siteStats(m.rounds)
+}
+
+// SiteStatsOf returns the site stats of several matches combined,
+// e.g. of a library of matches for veto preparation.
+func SiteStatsOf(matches ...*MatchReader) []SiteStats {
+	rounds := make([]*Reader, 0)
+	for _, m := range matches {
+		rounds = append(rounds, m.rounds...)
+	}
+	return siteStats(rounds)
+}
+
+func siteStats(rounds []*Reader) []SiteStats {
+	type key struct {
+		m    Map
+		site string
+	}
+	stats := make([]SiteStats, 0)
+	index := make(map[key]int)
+	spawns := make([]map[string]int, 0)
+	mapRounds := make(map[Map]int)
+	for _, r := range rounds {
+		h := r.Header
+		if (h.GameMode != Bomb && h.GameMode != QuickMatchBomb) || len(h.Site) == 0 {
+			continue
+		}
+		attack := -1
+		for i, t := range h.Teams {
+			if t.Role == Attack {
+				attack = i
+			}
+		}
+		if attack < 0 {
+			continue
+		}
+		k := key{h.Map, h.Site}
+		j, ok := index[k]
+		if !ok {
+			j = len(stats)
+			index[k] = j
+			stats = append(stats, SiteStats{Map: h.Map, Site: h.Site, Spawns: make([]SpawnStats, 0)})
+			spawns = append(spawns, make(map[string]int))
+		}
+		mapRounds[h.Map]++
+		s := &stats[j]
+		s.Rounds++
+		won := h.Teams[attack].Won
+		if won {
+			s.AttackWins++
+		} else if h.Teams[attack^1].Won {
+			s.DefenseWins++
+		}
+		if r.defuserPlanted() {
+			s.Plants++
+			if won {
+				s.PostPlantWins++
+			}
+		}
+		for _, p := range h.Players {
+			if p.TeamIndex != attack || len(p.Spawn) == 0 {
+				continue
+			}
+			l, ok := spawns[j][p.Spawn]
+			if !ok {
+				l = len(s.Spawns)
+				spawns[j][p.Spawn] = l
+				s.Spawns = append(s.Spawns, SpawnStats{Spawn: p.Spawn})
+			}
+			s.Spawns[l].Picks++
+			if won {
+				s.Spawns[l].RoundsWon++
+			}
+		}
+	}
+	for i := range stats {
+		s := &stats[i]
+		s.PickRate = percentage(s.Rounds, mapRounds[s.Map])
+		s.AttackWinRate = percentage(s.AttackWins, s.Rounds)
+		s.PlantRate = percentage(s.Plants, s.Rounds)
+		s.PostPlantWinRate = percentage(s.PostPlantWins, s.Plants)
+		for l := range s.Spawns {
+			s.Spawns[l].WinRate = percentage(s.Spawns[l].RoundsWon, s.Spawns[l].Picks)
+		}
+		slices.SortStableFunc(s.Spawns, func(a, b SpawnStats) int {
+			return cmp.Compare(b.Picks, a.Picks)
+		})
+	}
+	slices.SortStableFunc(stats, func(a, b SiteStats) int {
+		return cmp.Or(cmp.Compare(a.Map.String(), b.Map.String()), cmp.Compare(b.Rounds, a.Rounds), cmp.Compare(a.Site, b.Site))
+	})
+	return stats
+}
+
+// defuserPlanted returns true if the defuser was planted in the round.
+func (r *Reader) defuserPlanted() bool {
+	for _, u := range r.MatchFeedback {
+		if u.Type == DefuserPlantComplete {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+package dissect
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sqliteVersion is stored as the user_version of databases written by
+// SQLiteWriter. Version 1 keys match players by profile ID, and version 2
+// keys player round stats by profile ID.
+const sqliteVersion = 2
+
+// sqliteSchema is the normalized schema written by SQLiteWriter.
+// Rounds are numbered from 1. Players are keyed by profile ID, so a
+// player renamed during a match has a single row under their latest username.
+// Replays recorded before profile IDs store the username as the profile ID.
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS matches (
+		match_id TEXT PRIMARY KEY,
+		timestamp TEXT NOT NULL,
+		game_version TEXT NOT NULL,
+		code_version INTEGER NOT NULL,
+		match_type TEXT NOT NULL,
+		game_mode TEXT NOT NULL,
+		map TEXT NOT NULL,
+		recording_profile_id TEXT NOT NULL,
+		rounds_per_match INTEGER NOT NULL,
+		rounds_per_match_overtime INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS teams (
+		match_id TEXT NOT NULL REFERENCES matches (match_id),
+		team_index INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		PRIMARY KEY (match_id, team_index)
+	)`,
+	`CREATE TABLE IF NOT EXISTS players (
+		profile_id TEXT PRIMARY KEY,
+		username TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS match_players (
+		match_id TEXT NOT NULL REFERENCES matches (match_id),
+		username TEXT NOT NULL,
+		profile_id TEXT NOT NULL,
+		team_index INTEGER NOT NULL,
+		PRIMARY KEY (match_id, profile_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS rounds (
+		match_id TEXT NOT NULL REFERENCES matches (match_id),
+		round INTEGER NOT NULL,
+		overtime_round INTEGER NOT NULL,
+		site TEXT NOT NULL,
+		winning_team_index INTEGER,
+		win_condition TEXT NOT NULL,
+		PRIMARY KEY (match_id, round)
+	)`,
+	`CREATE TABLE IF NOT EXISTS round_teams (
+		match_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		team_index INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		score INTEGER NOT NULL,
+		won INTEGER NOT NULL,
+		PRIMARY KEY (match_id, round, team_index),
+		FOREIGN KEY (match_id, round) REFERENCES rounds (match_id, round)
+	)`,
+	`CREATE TABLE IF NOT EXISTS events (
+		match_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		event_index INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		username TEXT NOT NULL,
+		target TEXT NOT NULL,
+		headshot INTEGER,
+		time TEXT NOT NULL,
+		time_in_seconds REAL NOT NULL,
+		message TEXT NOT NULL,
+		operator TEXT NOT NULL,
+		PRIMARY KEY (match_id, round, event_index),
+		FOREIGN KEY (match_id, round) REFERENCES rounds (match_id, round)
+	)`,
+	`CREATE TABLE IF NOT EXISTS player_round_stats (
+		match_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		profile_id TEXT NOT NULL,
+		team_index INTEGER NOT NULL,
+		operator TEXT NOT NULL,
+		score INTEGER NOT NULL,
+		kills INTEGER NOT NULL,
+		died INTEGER NOT NULL,
+		assists INTEGER NOT NULL,
+		headshots INTEGER NOT NULL,
+		headshot_percentage REAL NOT NULL,
+		one_vx INTEGER NOT NULL,
+		PRIMARY KEY (match_id, round, profile_id),
+		FOREIGN KEY (match_id, round) REFERENCES rounds (match_id, round)
+	)`,
+	`CREATE TABLE IF NOT EXISTS player_match_stats (
+		match_id TEXT NOT NULL REFERENCES matches (match_id),
+		username TEXT NOT NULL,
+		profile_id TEXT NOT NULL,
+		team_index INTEGER NOT NULL,
+		rounds INTEGER NOT NULL,
+		kills INTEGER NOT NULL,
+		deaths INTEGER NOT NULL,
+		assists INTEGER NOT NULL,
+		headshots INTEGER NOT NULL,
+		headshot_percentage REAL NOT NULL,
+		PRIMARY KEY (match_id, profile_id)
+	)`,
+}
+
+// sqlitePlayerTables are the tables keyed by username before the version given.
+var sqlitePlayerTables = []struct {
+	name    string
+	version int
+}{
+	{"match_players", 1},
+	{"player_match_stats", 1},
+	{"player_round_stats", 2},
+}
+
+// SQLiteWriter writes matches and rounds to a normalized SQLite schema.
+// Rows are upserted by match id and round number, so writing the same
+// replays again replaces their rows instead of duplicating them.
+//
+// The caller opens db with a SQLite driver of their choice.
+type SQLiteWriter struct {
+	db *sql.DB
+}
+
+// NewSQLiteWriter creates the schema in db if it does not exist yet,
+// and migrates databases written by earlier versions.
+func NewSQLiteWriter(db *sql.DB) (*SQLiteWriter, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var version int
+	if err = tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return nil, err
+	}
+	// tables still keyed by username are recreated with the current schema
+	rekey := make([]string, 0)
+	for _, table := range sqlitePlayerTables {
+		if version >= table.version {
+			continue
+		}
+		var exists bool
+		err = tx.QueryRow("SELECT count(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?", table.name).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		if _, err = tx.Exec("ALTER TABLE " + table.name + " RENAME TO " + table.name + "_old"); err != nil {
+			return nil, err
+		}
+		rekey = append(rekey, table.name)
+	}
+	for _, stmt := range sqliteSchema {
+		if _, err = tx.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+	for _, table := range rekey {
+		// the columns are unchanged, the latest row of a renamed player wins
+		migrate := []string{
+			"UPDATE " + table + "_old SET profile_id = username WHERE profile_id = ''",
+			"INSERT OR REPLACE INTO " + table + " SELECT * FROM " + table + "_old ORDER BY rowid",
+			"DROP TABLE " + table + "_old",
+		}
+		for _, stmt := range migrate {
+			if _, err = tx.Exec(stmt); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if _, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteVersion)); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &SQLiteWriter{db}, nil
+}
+
+// WriteMatch writes the match, its rounds, events and player stats
+// in a single transaction.
+func (w *SQLiteWriter) WriteMatch(m *MatchReader) error {
+	if len(m.rounds) == 0 {
+		return nil
+	}
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	last := m.rounds[len(m.rounds)-1]
+	if err = writeSQLiteMatch(tx, last.Header); err != nil {
+		return err
+	}
+	for _, r := range m.rounds {
+		if err = writeSQLiteRound(tx, r); err != nil {
+			return err
+		}
+	}
+	matchID := last.Header.MatchID
+	if _, err = tx.Exec("DELETE FROM player_match_stats WHERE match_id = ?", matchID); err != nil {
+		return err
+	}
+	for _, s := range m.PlayerStats() {
+		_, err = tx.Exec(sqliteInsert("player_match_stats",
+			"match_id", "username", "profile_id", "team_index", "rounds",
+			"kills", "deaths", "assists", "headshots", "headshot_percentage"),
+			matchID, s.Username, playerKey(s.ProfileID, s.Username), s.TeamIndex, s.Rounds,
+			s.Kills, s.Deaths, s.Assists, s.Headshots, s.HeadshotPercentage)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// WriteRound writes a single round and the match it belongs to
+// in a single transaction.
+func (w *SQLiteWriter) WriteRound(r *Reader) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = writeSQLiteMatch(tx, r.Header); err != nil {
+		return err
+	}
+	if err = writeSQLiteRound(tx, r); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func writeSQLiteMatch(tx *sql.Tx, h Header) error {
+	_, err := tx.Exec(sqliteUpsert("matches", []string{"match_id"},
+		"timestamp", "game_version", "code_version", "match_type", "game_mode", "map",
+		"recording_profile_id", "rounds_per_match", "rounds_per_match_overtime"),
+		h.MatchID, h.Timestamp.Format(time.RFC3339), h.GameVersion, h.CodeVersion,
+		h.MatchType.String(), h.GameMode.String(), h.Map.String(),
+		h.RecordingProfileID, h.RoundsPerMatch, h.RoundsPerMatchOvertime)
+	if err != nil {
+		return err
+	}
+	for i, t := range h.Teams {
+		_, err = tx.Exec(sqliteUpsert("teams", []string{"match_id", "team_index"}, "name"),
+			h.MatchID, i, t.Name)
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range h.Players {
+		if len(p.ProfileID) > 0 {
+			_, err = tx.Exec(sqliteUpsert("players", []string{"profile_id"}, "username"),
+				p.ProfileID, p.Username)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(sqliteUpsert("match_players", []string{"match_id", "profile_id"}, "username", "team_index"),
+			h.MatchID, playerKey(p.ProfileID, p.Username), p.Username, p.TeamIndex)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSQLiteRound(tx *sql.Tx, r *Reader) error {
+	h := r.Header
+	round := h.RoundNumber + 1
+	var winningTeamIndex any
+	var winCondition WinCondition
+	for i, t := range h.Teams {
+		if t.Won {
+			winningTeamIndex = i
+			winCondition = t.WinCondition
+		}
+	}
+	_, err := tx.Exec(sqliteUpsert("rounds", []string{"match_id", "round"},
+		"overtime_round", "site", "winning_team_index", "win_condition"),
+		h.MatchID, round, h.OvertimeRoundNumber, h.Site, winningTeamIndex, string(winCondition))
+	if err != nil {
+		return err
+	}
+	// child rows are replaced as a whole since their count may change between reads
+	for _, table := range []string{"round_teams", "events", "player_round_stats"} {
+		if _, err = tx.Exec("DELETE FROM "+table+" WHERE match_id = ? AND round = ?", h.MatchID, round); err != nil {
+			return err
+		}
+	}
+	for i, t := range h.Teams {
+		_, err = tx.Exec(sqliteInsert("round_teams", "match_id", "round", "team_index", "role", "score", "won"),
+			h.MatchID, round, i, string(t.Role), t.Score, t.Won)
+		if err != nil {
+			return err
+		}
+	}
+	for i, u := range r.MatchFeedback {
+		var headshot any
+		if u.Headshot != nil {
+			headshot = *u.Headshot
+		}
+		operator := ""
+		if u.Operator != 0 {
+			operator = u.Operator.String()
+		}
+		_, err = tx.Exec(sqliteInsert("events",
+			"match_id", "round", "event_index", "type", "username", "target",
+			"headshot", "time", "time_in_seconds", "message", "operator"),
+			h.MatchID, round, i, u.Type.String(), u.Username, u.Target,
+			headshot, u.Time, u.TimeInSeconds, u.Message, operator)
+		if err != nil {
+			return err
+		}
+	}
+	for i, s := range r.PlayerStats() {
+		_, err = tx.Exec(sqliteInsert("player_round_stats",
+			"match_id", "round", "username", "profile_id", "team_index", "operator",
+			"score", "kills", "died", "assists", "headshots", "headshot_percentage", "one_vx"),
+			h.MatchID, round, s.Username, playerKey(s.ProfileID, s.Username), s.TeamIndex, h.Players[i].Operator.String(),
+			s.Score, s.Kills, s.Died, s.Assists, s.Headshots, s.HeadshotPercentage, s.OneVx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sqliteInsert(table string, columns ...string) string {
+	return "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" +
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+}
+
+// sqliteUpsert inserts keys followed by columns, or updates columns
+// when a row with the same keys exists.
+func sqliteUpsert(table string, keys []string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, c := range columns {
+		set[i] = c + " = excluded." + c
+	}
+	return sqliteInsert(table, append(append([]string{}, keys...), columns...)...) +
+		" ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(set, ", ")
+}
@@ -2,6 +2,7 @@ package dissect
 
 type PlayerRoundStats struct {
 	Username           string  `json:"username"`
+	ProfileID          string  `json:"profileID,omitempty"`
 	TeamIndex          int     `json:"-"`
 	Score              int     `json:"score"`
 	Operator           string  `json:"-"`
@@ -10,18 +11,46 @@ type PlayerRoundStats struct {
 	Assists            int     `json:"assists"`
 	Headshots          int     `json:"headshots"`
 	HeadshotPercentage float64 `json:"headshotPercentage"`
-	OneVx              int     `json:"1vX,omitempty"`
+	OneVx              int     `json:"1vX,omitempty"` // opponents of a won clutch
+	OpeningKill        bool    `json:"openingKill"`   // won the opening duel
+	OpeningDeath       bool    `json:"openingDeath"`  // lost the opening duel
+	Traded             bool    `json:"traded"`        // died and the killer was killed within the trade window
+	TradeKills         int     `json:"tradeKills"`    // kills trading a teammate
+	Planted            bool    `json:"planted"`       // completed a defuser plant
+	Defused            bool    `json:"defused"`       // completed a defuser disable
+	KOST               bool    `json:"kost"`          // kill, objective, survived or traded
+	Clutch             *Clutch `json:"clutch,omitempty"`
+	Rating             float64 `json:"rating"` // see RatingWeights
 }
 
 type PlayerMatchStats struct {
-	Username           string  `json:"username"`
-	TeamIndex          int     `json:"-"`
-	Rounds             int     `json:"rounds"`
-	Kills              int     `json:"kills"`
-	Deaths             int     `json:"deaths"`
-	Assists            int     `json:"assists"`
-	Headshots          int     `json:"headshots"`
-	HeadshotPercentage float64 `json:"headshotPercentage"`
+	Username           string             `json:"username"`
+	ProfileID          string             `json:"profileID,omitempty"`
+	TeamIndex          int                `json:"-"`
+	Rounds             int                `json:"rounds"`
+	Kills              int                `json:"kills"`
+	Deaths             int                `json:"deaths"`
+	Assists            int                `json:"assists"`
+	Headshots          int                `json:"headshots"`
+	HeadshotPercentage float64            `json:"headshotPercentage"`
+	KOST               float64            `json:"kost"`         // percentage of rounds with a kill, objective, survival or trade
+	KPR                float64            `json:"kpr"`          // kills per round
+	DPR                float64            `json:"dpr"`          // deaths per round
+	APR                float64            `json:"apr"`          // assists per round
+	SurvivalRate       float64            `json:"survivalRate"` // percentage of rounds survived
+	TwoKills           int                `json:"2k"`
+	ThreeKills         int                `json:"3k"`
+	FourKills          int                `json:"4k"`
+	FiveKills          int                `json:"5k"`
+	ClutchAttempts     int                `json:"clutchAttempts"`
+	ClutchWins         int                `json:"clutchWins"`
+	OpeningDuels       PlayerOpeningDuels `json:"openingDuels"`
+	TradeKills         int                `json:"tradeKills"`
+	TradedDeaths       int                `json:"tradedDeaths"`
+	UntradedDeaths     int                `json:"untradedDeaths"`
+	Rating             float64            `json:"rating"` // average round rating, see RatingWeights
+	kostRounds         int
+	ratingSum          float64
 }
 
 // OpeningKill returns the first player to kill.
@@ -44,21 +73,6 @@ func (r *Reader) OpeningDeath() MatchUpdate {
 	return MatchUpdate{}
 }
 
-// Trades returns KILL Activity pairs of trades.
-func (r *Reader) Trades() [][]MatchUpdate {
-	trades := make([][]MatchUpdate, 0)
-	var previous = MatchUpdate{}
-	for _, a := range r.MatchFeedback {
-		var samePlayers = (previous.Target == a.Username || previous.Username == a.Target)
-		var withinThreshold = (previous.TimeInSeconds - a.TimeInSeconds) <= 3
-		if a.Type == Kill && samePlayers && withinThreshold {
-			trades = append(trades, []MatchUpdate{previous, a})
-		}
-		previous = a
-	}
-	return trades
-}
-
 func (r *Reader) KillsAndDeaths() []MatchUpdate {
 	MatchFeedback := make([]MatchUpdate, 0)
 	for _, a := range r.MatchFeedback {
@@ -82,14 +96,11 @@ func (r *Reader) NumPlayers(team int) int {
 func (r *Reader) PlayerStats() []PlayerRoundStats {
 	stats := make([]PlayerRoundStats, 0)
 	index := make(map[string]int)
-	winningTeamIndex := 0
-	if r.Header.Teams[1].Won {
-		winningTeamIndex = 1
-	}
 	for i, p := range r.Header.Players {
 		scorePlayer := r.Scoreboard.Players[i]
 		stats = append(stats, PlayerRoundStats{
 			Username:  p.Username,
+			ProfileID: p.ProfileID,
 			TeamIndex: p.TeamIndex,
 			Operator:  p.Operator.String(),
 			Assists:   int(scorePlayer.AssistsFromRound),
@@ -97,98 +108,158 @@ func (r *Reader) PlayerStats() []PlayerRoundStats {
 		})
 		index[p.Username] = i
 	}
-	lastDeath := -1
 	for _, a := range r.MatchFeedback {
-		i := index[a.Username]
+		i, ok := index[a.Username]
 		if a.Type == Kill {
-			stats[i].Kills += 1
-			if *a.Headshot {
-				stats[i].Headshots += 1
+			if ok {
+				stats[i].Kills += 1
+				if a.Headshot != nil && *a.Headshot {
+					stats[i].Headshots += 1
+				}
+				stats[i].HeadshotPercentage = headshotPercentage(stats[i].Headshots, stats[i].Kills)
+			}
+			if target, ok := index[a.Target]; ok {
+				stats[target].Died = true
 			}
-			stats[i].HeadshotPercentage = headshotPercentage(stats[i].Headshots, stats[i].Kills)
-			stats[index[a.Target]].Died = true
-			lastDeath = index[a.Target]
-		} else if a.Type == Death {
+		} else if a.Type == Death && ok {
 			stats[i].Died = true
-			lastDeath = i
 		}
 	}
-	// Calculates 1vX
-	winnersLeftAlive := make([]int, 0)
-	lastDeathWasWinner := false
-	for i, p := range r.Header.Players {
-		if p.TeamIndex != winningTeamIndex {
+	trades := r.TradesWithin(r.TradeWindow())
+	for j, trade := range trades {
+		if i, ok := index[trade.Traded]; ok {
+			stats[i].Traded = true
+		}
+		// a refrag trading several deaths is a single trade kill
+		if j > 0 && trades[j-1].Refrag == trade.Refrag {
 			continue
 		}
-		if !stats[i].Died {
-			winnersLeftAlive = append(winnersLeftAlive, i)
+		if i, ok := index[trade.Trader]; ok {
+			stats[i].TradeKills++
+		}
+	}
+	for _, a := range r.MatchFeedback {
+		i, ok := index[a.Username]
+		if !ok {
+			continue
 		}
-		if i == lastDeath {
-			lastDeathWasWinner = true
+		if a.Type == DefuserPlantComplete {
+			stats[i].Planted = true
+		} else if a.Type == DefuserDisableComplete {
+			stats[i].Defused = true
 		}
 	}
-	nWinnersLeftAlive := len(winnersLeftAlive)
-	lastWinnerStanding := -1
-	if nWinnersLeftAlive == 1 {
-		lastWinnerStanding = winnersLeftAlive[0]
-	} else if nWinnersLeftAlive == 0 && lastDeathWasWinner {
-		lastWinnerStanding = lastDeath
-	}
-	if lastWinnerStanding > -1 {
-		username := stats[lastWinnerStanding].Username
-		teamLeft := r.NumPlayers(winningTeamIndex)
-		oneVx := 0
-		for _, a := range r.MatchFeedback {
-			if a.Type == Kill && stats[index[a.Target]].TeamIndex == winningTeamIndex {
-				teamLeft--
-			} else if a.Type == Death && stats[index[a.Username]].TeamIndex == winningTeamIndex {
-				teamLeft--
-			} else if a.Type == PlayerLeave && stats[index[a.Username]].TeamIndex == winningTeamIndex {
-				teamLeft--
-			}
-			if a.Username != username {
-				continue
-			}
-			if a.Type == Kill && teamLeft < 2 {
-				oneVx++
-			}
+	if duel, ok := r.OpeningDuel(); ok {
+		if i, ok := index[duel.Killer]; ok {
+			stats[i].OpeningKill = true
+		}
+		if i, ok := index[duel.Victim]; ok {
+			stats[i].OpeningDeath = true
 		}
-		for _, s := range stats {
-			if s.TeamIndex != winningTeamIndex && !s.Died {
-				oneVx++
+	}
+	for _, c := range r.Clutches() {
+		if i, ok := index[c.Username]; ok {
+			clutch := c
+			stats[i].Clutch = &clutch
+			if c.Outcome == ClutchWon {
+				stats[i].OneVx = c.Opponents
 			}
 		}
-		stats[lastWinnerStanding].OneVx = oneVx
+	}
+	for i := range stats {
+		s := &stats[i]
+		s.KOST = s.Kills > 0 || s.Planted || s.Defused || !s.Died || s.Traded
+		s.Rating = r.RatingWeights().Round(*s)
 	}
 	return stats
 }
 
+// PlayerStats returns the stats of every player in the match. Players are
+// keyed by profile ID, so a player renamed between rounds is counted once
+// under their latest username.
 func (m *MatchReader) PlayerStats() []PlayerMatchStats {
 	stats := make([]PlayerMatchStats, 0)
 	index := make(map[string]int)
-	for i, r := range m.rounds {
+	for _, r := range m.rounds {
 		for _, p := range r.PlayerStats() {
-			if len(stats) == 0 || stats[index[p.Username]].Username != p.Username {
-				stats = append(stats, PlayerMatchStats{
-					Username:  p.Username,
-					TeamIndex: p.TeamIndex,
-				})
-				index[p.Username] = len(index)
-			}
-			i = index[p.Username]
-			stats[i].Rounds += 1
-			stats[i].Kills += p.Kills
-			if p.Died {
-				stats[i].Deaths += 1
+			key := playerKey(p.ProfileID, p.Username)
+			i, ok := index[key]
+			if !ok {
+				i = len(stats)
+				index[key] = i
+				stats = append(stats, PlayerMatchStats{ProfileID: p.ProfileID})
 			}
-			stats[i].Assists += p.Assists
-			stats[i].Headshots += p.Headshots
-			stats[i].HeadshotPercentage = headshotPercentage(stats[i].Headshots, stats[i].Kills)
+			stats[i].Username = p.Username
+			stats[i].TeamIndex = p.TeamIndex
+			stats[i].add(r, p)
 		}
 	}
+	for i := range stats {
+		stats[i].rates()
+	}
 	return stats
 }
 
+// playerKey identifies a player by profile ID, or by username
+// for replays recorded before profile IDs.
+func playerKey(profileID, username string) string {
+	if len(profileID) > 0 {
+		return profileID
+	}
+	return username
+}
+
+func (s *PlayerMatchStats) add(r *Reader, p PlayerRoundStats) {
+	s.Rounds += 1
+	s.Kills += p.Kills
+	if p.Died {
+		s.Deaths += 1
+	}
+	s.Assists += p.Assists
+	s.Headshots += p.Headshots
+	switch p.Kills {
+	case 2:
+		s.TwoKills++
+	case 3:
+		s.ThreeKills++
+	case 4:
+		s.FourKills++
+	case 5:
+		s.FiveKills++
+	}
+	s.TradeKills += p.TradeKills
+	if p.Traded {
+		s.TradedDeaths++
+	} else if p.Died {
+		s.UntradedDeaths++
+	}
+	if p.KOST {
+		s.kostRounds++
+	}
+	s.ratingSum += p.Rating
+	if p.OpeningKill || p.OpeningDeath {
+		s.OpeningDuels.add(r.Header.Teams[p.TeamIndex].Role, p.OpeningKill)
+	}
+	if p.Clutch != nil {
+		s.ClutchAttempts++
+		if p.Clutch.Outcome == ClutchWon {
+			s.ClutchWins++
+		}
+	}
+}
+
+// rates derives the percentages and per round rates from the counts.
+func (s *PlayerMatchStats) rates() {
+	rounds := float64(s.Rounds)
+	s.HeadshotPercentage = headshotPercentage(s.Headshots, s.Kills)
+	s.KOST = float64(s.kostRounds) / rounds * 100
+	s.KPR = float64(s.Kills) / rounds
+	s.DPR = float64(s.Deaths) / rounds
+	s.APR = float64(s.Assists) / rounds
+	s.SurvivalRate = float64(s.Rounds-s.Deaths) / rounds * 100
+	s.Rating = s.ratingSum / rounds
+}
+
 func headshotPercentage(headshots, kills int) float64 {
 	if kills == 0 {
 		return 0
@@ -0,0 +1,151 @@
+package dissect
+
+// TeamMatchStats are the stats of a team over the rounds of a match.
+type TeamMatchStats struct {
+	Name                   string               `json:"name"`
+	Rounds                 int                  `json:"rounds"`
+	RoundsWon              int                  `json:"roundsWon"`
+	AttackRounds           int                  `json:"attackRounds"`
+	AttackWins             int                  `json:"attackWins"`
+	AttackWinRate          float64              `json:"attackWinRate"`
+	DefenseRounds          int                  `json:"defenseRounds"`
+	DefenseWins            int                  `json:"defenseWins"`
+	DefenseWinRate         float64              `json:"defenseWinRate"`
+	WinConditions          map[WinCondition]int `json:"winConditions"` // rounds won by win condition
+	Plants                 int                  `json:"plants"`
+	PlantWins              int                  `json:"plantWins"`
+	PlantConversion        float64              `json:"plantConversion"` // percentage of plants won
+	Retakes                int                  `json:"retakes"`         // rounds defending a planted defuser
+	RetakeWins             int                  `json:"retakeWins"`
+	RetakeSuccessRate      float64              `json:"retakeSuccessRate"`
+	OpeningDuelWins        int                  `json:"openingDuelWins"` // see TeamOpeningDuels
+	OpeningDuelLosses      int                  `json:"openingDuelLosses"`
+	OpeningDuelWinRate     float64              `json:"openingDuelWinRate"`
+	AverageFirstKill       float64              `json:"averageFirstKill"`   // seconds into the action phase of the first kill of the team
+	ManAdvantageRounds     int                  `json:"manAdvantageRounds"` // rounds the team was first to have more players alive by going 5v4
+	ManAdvantageWins       int                  `json:"manAdvantageWins"`
+	ManAdvantageConversion float64              `json:"manAdvantageConversion"` // percentage of rounds won after going 5v4
+	FirstAdvantageRounds   int                  `json:"firstAdvantageRounds"`   // rounds the team was first to have more players alive
+	FirstAdvantageWins     int                  `json:"firstAdvantageWins"`
+	FirstAdvantageWinRate  float64              `json:"firstAdvantageWinRate"`
+	FirstDeficitRounds     int                  `json:"firstDeficitRounds"` // rounds the team was first to have fewer players alive, e.g. 4v5
+	FirstDeficitWins       int                  `json:"firstDeficitWins"`
+	FirstDeficitWinRate    float64              `json:"firstDeficitWinRate"`
+	firstKills             int
+	firstKillSum           float64
+}
+
+// TeamStats returns the stats of both teams.
+func (m *MatchReader) TeamStats() [2]TeamMatchStats {
+	teams := [2]TeamMatchStats{}
+	for i := range teams {
+		teams[i].WinConditions = make(map[WinCondition]int)
+	}
+	for _, r := range m.rounds {
+		planted := r.defuserPlanted()
+		firstKills := r.firstKills()
+		ahead, at, ok := r.firstAdvantage()
+		for i := range teams {
+			t := &teams[i]
+			team := r.Header.Teams[i]
+			t.Name = team.Name
+			t.Rounds++
+			if team.Won {
+				t.RoundsWon++
+				t.WinConditions[team.WinCondition]++
+			}
+			switch team.Role {
+			case Attack:
+				t.AttackRounds++
+				if team.Won {
+					t.AttackWins++
+				}
+				if planted {
+					t.Plants++
+					if team.Won {
+						t.PlantWins++
+					}
+				}
+			case Defense:
+				t.DefenseRounds++
+				if team.Won {
+					t.DefenseWins++
+				}
+				if planted {
+					t.Retakes++
+					if team.Won {
+						t.RetakeWins++
+					}
+				}
+			}
+			if ok && ahead == i {
+				t.FirstAdvantageRounds++
+				if team.Won {
+					t.FirstAdvantageWins++
+				}
+				if at.Alive[i] == 5 && at.Alive[1-i] == 4 {
+					t.ManAdvantageRounds++
+					if team.Won {
+						t.ManAdvantageWins++
+					}
+				}
+			} else if ok {
+				t.FirstDeficitRounds++
+				if team.Won {
+					t.FirstDeficitWins++
+				}
+			}
+			if seconds, ok := firstKills[i]; ok {
+				t.firstKills++
+				t.firstKillSum += seconds
+			}
+		}
+	}
+	duels := m.TeamOpeningDuels()
+	for i := range teams {
+		t := &teams[i]
+		t.OpeningDuelWins = duels[i].Wins
+		t.OpeningDuelLosses = duels[i].Losses
+		t.OpeningDuelWinRate = duels[i].WinRate
+		t.AttackWinRate = percentage(t.AttackWins, t.AttackRounds)
+		t.DefenseWinRate = percentage(t.DefenseWins, t.DefenseRounds)
+		t.PlantConversion = percentage(t.PlantWins, t.Plants)
+		t.RetakeSuccessRate = percentage(t.RetakeWins, t.Retakes)
+		t.ManAdvantageConversion = percentage(t.ManAdvantageWins, t.ManAdvantageRounds)
+		t.FirstAdvantageWinRate = percentage(t.FirstAdvantageWins, t.FirstAdvantageRounds)
+		t.FirstDeficitWinRate = percentage(t.FirstDeficitWins, t.FirstDeficitRounds)
+		if t.firstKills > 0 {
+			t.AverageFirstKill = t.firstKillSum / float64(t.firstKills)
+		}
+	}
+	return teams
+}
+
+// actionPhase is the number of seconds on the clock when the action phase of a round starts.
+const actionPhase = 180
+
+// firstKills returns the seconds into the action phase of the first
+// opponent kill of each team that killed an opponent.
+func (r *Reader) firstKills() map[int]float64 {
+	kills := make(map[int]float64)
+	offset := 0.0 // seconds of the action phase before the defuser was planted
+	for _, u := range r.MatchFeedback {
+		if u.Type == DefuserPlantComplete {
+			offset = actionPhase - u.TimeInSeconds
+			continue
+		}
+		if !r.opponentKill(u) {
+			continue
+		}
+		team := r.teamIndex(u.Username)
+		if _, ok := kills[team]; ok {
+			continue
+		}
+		if offset > 0 {
+			kills[team] = offset + defuserTimer - u.TimeInSeconds
+		} else {
+			kills[team] = actionPhase - u.TimeInSeconds
+		}
+	}
+	return kills
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestAliveTimeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		feedback []dissect.MatchUpdate
+		want     [][2]int
+	}{
+		{
+			name:     "no deaths",
+			feedback: []dissect.MatchUpdate{event(dissect.DefuserPlantComplete, "a1", 30)},
+			want:     [][2]int{{5, 5}},
+		},
+		{
+			name: "kills, deaths and players leaving",
+			feedback: []dissect.MatchUpdate{
+				kill("a1", "b1", 170),
+				event(dissect.Death, "a2", 160),
+				event(dissect.PlayerLeave, "b2", 150),
+			},
+			want: [][2]int{{5, 5}, {5, 4}, {4, 4}, {4, 3}},
+		},
+		{
+			name: "unrelated events",
+			feedback: []dissect.MatchUpdate{
+				event(dissect.OperatorSwap, "a1", 175),
+				kill("a1", "b1", 170),
+				event(dissect.LocateObjective, "a1", 165),
+				event(dissect.DefuserPlantStart, "a1", 60),
+			},
+			want: [][2]int{{5, 5}, {5, 4}},
+		},
+		{
+			name:     "team kill",
+			feedback: []dissect.MatchUpdate{kill("a1", "a2", 170)},
+			want:     [][2]int{{5, 5}, {4, 5}},
+		},
+		{
+			name: "players removed twice",
+			feedback: []dissect.MatchUpdate{
+				kill("a1", "b1", 170),
+				event(dissect.Death, "b1", 170),
+				event(dissect.PlayerLeave, "b1", 120),
+			},
+			want: [][2]int{{5, 5}, {5, 4}},
+		},
+		{
+			name:     "unknown players",
+			feedback: []dissect.MatchUpdate{kill("a1", "c1", 170), event(dissect.PlayerLeave, "c2", 160)},
+			want:     [][2]int{{5, 5}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			timeline := syntheticRound(0, test.feedback...).Reader().AliveTimeline()
+			if len(timeline) != len(test.want) {
+				t.Fatalf("AliveTimeline(): expected %v, got %+v", test.want, timeline)
+			}
+			if timeline[0].Cause != nil {
+				t.Errorf("AliveTimeline(): expected no cause at the start of the round, got %+v", timeline[0].Cause)
+			}
+			for i, want := range test.want {
+				if timeline[i].Alive != want {
+					t.Errorf("count %d: expected %v, got %v", i, want, timeline[i].Alive)
+				}
+				if i > 0 && timeline[i].Cause == nil {
+					t.Errorf("count %d: expected a cause", i)
+				}
+			}
+		})
+	}
+}
+
+func TestAliveTimeline_Cause(t *testing.T) {
+	timeline := syntheticRound(0, kill("a1", "b1", 170)).Reader().AliveTimeline()
+	c := timeline[1]
+	if c.TimeInSeconds != 170 || c.Cause.Username != "a1" || c.Cause.Target != "b1" {
+		t.Errorf("AliveTimeline(): expected the kill of b1 at 170, got %+v", c)
+	}
+}
+
+// TestClutches_AliveTimeline checks that every clutch starts at a count
+// of the timeline with the clutching team down to one player.
+func TestClutches_AliveTimeline(t *testing.T) {
+	r := syntheticRound(1,
+		kill("a1", "b2", 100),
+		kill("a1", "b3", 99),
+		kill("a1", "b4", 98),
+		kill("b1", "a2", 97),
+		event(dissect.PlayerLeave, "a3", 96),
+		kill("b1", "a4", 95),
+		kill("a1", "b5", 94),
+		kill("b1", "a5", 93),
+		kill("b1", "a1", 90),
+	).Reader()
+	timeline := r.AliveTimeline()
+	clutches := r.Clutches()
+	if len(clutches) != 2 {
+		t.Fatalf("Clutches(): expected 2 clutches, got %+v", clutches)
+	}
+	for _, clutch := range clutches {
+		found := false
+		for _, c := range timeline {
+			if c.TimeInSeconds == clutch.TimeInSeconds {
+				found = c.Alive[clutch.TeamIndex] == 1 && c.Alive[1-clutch.TeamIndex] == clutch.Opponents
+			}
+		}
+		if !found {
+			t.Errorf("clutch %+v does not match the alive timeline %+v", clutch, timeline)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// renamedMatch loads the example round as a match of two rounds,
+// with a player renamed in the second round.
+func renamedMatch(t *testing.T, from, to string) *dissect.MatchReader {
+	f, err := os.Open("../../examples/unranked_R01.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := dissect.ReadRoundJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := r.Data()
+	second := r.Data()
+	second.Players = slices.Clone(first.Players)
+	second.MatchFeedback = slices.Clone(first.MatchFeedback)
+	second.PlayerStats = slices.Clone(first.PlayerStats)
+	for i := range second.Players {
+		if second.Players[i].Username == from {
+			second.Players[i].Username = to
+		}
+	}
+	for i, u := range second.MatchFeedback {
+		if u.Username == from {
+			second.MatchFeedback[i].Username = to
+		}
+		if u.Target == from {
+			second.MatchFeedback[i].Target = to
+		}
+	}
+	for i := range second.PlayerStats {
+		if second.PlayerStats[i].Username == from {
+			second.PlayerStats[i].Username = to
+		}
+	}
+	return dissect.MatchData{Rounds: []dissect.RoundData{first, second}}.MatchReader()
+}
+
+func TestMatchPlayerStats_Renamed(t *testing.T) {
+	m := renamedMatch(t, "redraskal", "renamed")
+	stats := m.PlayerStats()
+	if len(stats) != 10 {
+		t.Fatalf("PlayerStats(): expected 10 players, got %d", len(stats))
+	}
+	for _, s := range stats {
+		if s.Rounds != 2 {
+			t.Errorf("PlayerStats(): expected 2 rounds for %s, got %d", s.Username, s.Rounds)
+		}
+	}
+}
+
+func TestCareerStatsOf(t *testing.T) {
+	m := renamedMatch(t, "redraskal", "renamed")
+	careers := dissect.CareerStatsOf(m, m)
+	if len(careers) != 10 {
+		t.Fatalf("CareerStatsOf(): expected 10 players, got %d", len(careers))
+	}
+	i := slices.IndexFunc(careers, func(c dissect.CareerStats) bool { return c.Username == "renamed" })
+	if i < 0 {
+		t.Fatal("CareerStatsOf(): renamed player not found")
+	}
+	c := careers[i]
+	if diff := sliceDiff([]string{"redraskal", "renamed"}, c.Usernames); len(diff) > 0 || len(c.Usernames) != 2 {
+		t.Errorf("Usernames: expected [redraskal renamed], got %v", c.Usernames)
+	}
+	if c.Matches != 2 || c.Totals.Rounds != 4 {
+		t.Errorf("expected 2 matches and 4 rounds, got %d and %d", c.Matches, c.Totals.Rounds)
+	}
+	if len(c.Maps) != 1 || c.Maps[0].Stats.Rounds != 4 {
+		t.Errorf("Maps: expected a single map split of 4 rounds, got %+v", c.Maps)
+	}
+}
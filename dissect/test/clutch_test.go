@@ -0,0 +1,132 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// teamDown returns the kills of team 0 players a2 to a5 by b1, leaving a1 alone.
+func teamDown(seconds float64) []dissect.MatchUpdate {
+	return []dissect.MatchUpdate{
+		kill("b1", "a2", seconds),
+		kill("b1", "a3", seconds-1),
+		kill("b1", "a4", seconds-2),
+		kill("b1", "a5", seconds-3),
+	}
+}
+
+func TestClutches(t *testing.T) {
+	tests := []struct {
+		name     string
+		winner   int
+		feedback []dissect.MatchUpdate
+		want     []dissect.Clutch
+	}{
+		{
+			name:   "won",
+			winner: 0,
+			feedback: append(teamDown(100),
+				kill("a1", "b1", 90),
+				kill("a1", "b2", 80),
+				kill("a1", "b3", 70),
+				kill("a1", "b4", 60),
+				kill("a1", "b5", 50),
+			),
+			// the last defender is in a 1v1 once b4 dies
+			want: []dissect.Clutch{
+				{Username: "a1", TeamIndex: 0, Opponents: 5, TimeInSeconds: 97, Kills: 5, Outcome: dissect.ClutchWon},
+				{Username: "b5", TeamIndex: 1, Opponents: 1, TimeInSeconds: 60, Outcome: dissect.ClutchLost},
+			},
+		},
+		{
+			name:   "lost",
+			winner: 1,
+			feedback: append(teamDown(100),
+				kill("a1", "b1", 90),
+				kill("b2", "a1", 80),
+			),
+			want: []dissect.Clutch{{Username: "a1", TeamIndex: 0, Opponents: 5, TimeInSeconds: 97, Kills: 1, Outcome: dissect.ClutchLost}},
+		},
+		{
+			name:     "saved",
+			winner:   1,
+			feedback: teamDown(100),
+			want:     []dissect.Clutch{{Username: "a1", TeamIndex: 0, Opponents: 5, TimeInSeconds: 97, Outcome: dissect.ClutchSaved}},
+		},
+		{
+			name:   "teammate leaves",
+			winner: 1,
+			feedback: []dissect.MatchUpdate{
+				kill("b1", "a2", 100),
+				kill("b1", "a3", 99),
+				kill("b1", "a4", 98),
+				event(dissect.PlayerLeave, "a5", 97),
+				kill("b1", "a1", 90),
+			},
+			want: []dissect.Clutch{{Username: "a1", TeamIndex: 0, Opponents: 5, TimeInSeconds: 97, Outcome: dissect.ClutchLost}},
+		},
+		{
+			name:   "clutching player leaves",
+			winner: 1,
+			feedback: append(teamDown(100),
+				kill("a1", "b1", 90),
+				event(dissect.PlayerLeave, "a1", 80),
+			),
+			want: []dissect.Clutch{{Username: "a1", TeamIndex: 0, Opponents: 5, TimeInSeconds: 97, Kills: 1, Outcome: dissect.ClutchLost}},
+		},
+		{
+			name:   "opponent leaves",
+			winner: 0,
+			feedback: append(teamDown(100),
+				event(dissect.PlayerLeave, "b2", 95),
+				kill("a1", "b1", 90),
+				kill("a1", "b3", 80),
+				kill("a1", "b4", 70),
+				kill("a1", "b5", 60),
+			),
+			want: []dissect.Clutch{
+				{Username: "a1", TeamIndex: 0, Opponents: 5, TimeInSeconds: 97, Kills: 4, Outcome: dissect.ClutchWon},
+				{Username: "b5", TeamIndex: 1, Opponents: 1, TimeInSeconds: 70, Outcome: dissect.ClutchLost},
+			},
+		},
+		{
+			name:   "both teams",
+			winner: 1,
+			feedback: []dissect.MatchUpdate{
+				kill("a1", "b2", 100),
+				kill("a1", "b3", 99),
+				kill("a1", "b4", 98),
+				kill("b1", "a2", 97),
+				kill("b1", "a3", 96),
+				kill("b1", "a4", 95),
+				kill("a1", "b5", 94),
+				kill("b1", "a5", 93),
+				kill("b1", "a1", 90),
+			},
+			want: []dissect.Clutch{
+				{Username: "b1", TeamIndex: 1, Opponents: 2, TimeInSeconds: 94, Kills: 2, Outcome: dissect.ClutchWon},
+				{Username: "a1", TeamIndex: 0, Opponents: 1, TimeInSeconds: 93, Outcome: dissect.ClutchLost},
+			},
+		},
+		{
+			name:     "no clutch",
+			winner:   0,
+			feedback: []dissect.MatchUpdate{kill("a1", "b1", 100), kill("b2", "a2", 90)},
+			want:     []dissect.Clutch{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := syntheticRound(test.winner, test.feedback...).Reader().Clutches()
+			if len(got) != len(test.want) {
+				t.Fatalf("Clutches(): expected %d clutches, got %+v", len(test.want), got)
+			}
+			for i, want := range test.want {
+				if got[i] != want {
+					t.Errorf("clutch %d: expected %+v, got %+v", i, want, got[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// csvRound returns the first round of a ranked match on Villa,
+// won by team 0 after a1 kills b1 with an Ash.
+func csvRound() dissect.RoundData {
+	data := syntheticRound(0, kill("a1", "b1", 120))
+	data.MatchID = "match-1"
+	data.Timestamp = time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	data.GameVersion = "Y9S1"
+	data.CodeVersion = 8000000
+	data.MatchType = dissect.Ranked
+	data.GameMode = dissect.Bomb
+	data.Map = dissect.Villa
+	data.Site = "2F Aviator Room"
+	data.RecordingProfileID = "id-a1"
+	data.Teams[0].Name, data.Teams[1].Name = "Blue", "Orange"
+	data.Teams[0].WinCondition = dissect.KilledOpponents
+	data.Players[0].Operator = dissect.Ash
+	data.MatchFeedback[0].Time = "2:00"
+	return data
+}
+
+// readCSV returns the records of every table written to buffers.
+func readCSV(t *testing.T, buffers []*bytes.Buffer) [][][]string {
+	t.Helper()
+	tables := make([][][]string, 0, len(buffers))
+	for _, b := range buffers {
+		records, err := csv.NewReader(b).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, records)
+	}
+	return tables
+}
+
+func TestCSVWriter(t *testing.T) {
+	buffers := []*bytes.Buffer{{}, {}, {}, {}, {}}
+	w, err := dissect.NewCSVWriter(buffers[0], buffers[1], buffers[2], buffers[3], buffers[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := dissect.MatchData{Rounds: []dissect.RoundData{csvRound()}}.MatchReader()
+	if err = w.WriteMatch(m); err != nil {
+		t.Fatalf("WriteMatch(): expected no error, got %v", err)
+	}
+	tables := readCSV(t, buffers)
+	tests := []struct {
+		name   string
+		header []string
+		row    []string // the first row
+		rows   int
+	}{
+		{
+			name: dissect.MatchesCSV,
+			header: []string{
+				"match_id", "timestamp", "game_version", "code_version",
+				"match_type", "match_type_id", "game_mode", "game_mode_id", "map", "map_id",
+				"recording_profile_id", "team0_name", "team1_name", "team0_score", "team1_score", "rounds",
+			},
+			row: []string{
+				"match-1", "2024-03-01T20:00:00Z", "Y9S1", "8000000",
+				"Ranked", "2", "Bomb", "327933806", "Villa", "88107330328",
+				"id-a1", "Blue", "Orange", "1", "0", "1",
+			},
+			rows: 1,
+		},
+		{
+			name: dissect.RoundsCSV,
+			header: []string{
+				"match_id", "round", "overtime_round", "site", "team0_role", "team1_role",
+				"team0_score", "team1_score", "winning_team_index", "win_condition",
+			},
+			row:  []string{"match-1", "1", "0", "2F Aviator Room", "Attack", "Defense", "1", "0", "0", "KilledOpponents"},
+			rows: 1,
+		},
+		{
+			name: dissect.EventsCSV,
+			header: []string{
+				"match_id", "round", "event_index", "type", "type_id",
+				"username", "profile_id", "target", "target_profile_id", "headshot",
+				"time", "time_in_seconds", "message", "operator", "operator_id",
+			},
+			row:  []string{"match-1", "1", "0", "Kill", "0", "a1", "id-a1", "b1", "id-b1", "false", "2:00", "120", "", "", ""},
+			rows: 1,
+		},
+		{
+			name: dissect.PlayerRoundStatsCSV,
+			header: []string{
+				"match_id", "round", "profile_id", "username", "team_index", "operator", "operator_id",
+				"score", "kills", "died", "assists", "headshots", "headshot_percentage", "one_vx",
+			},
+			row:  []string{"match-1", "1", "id-a1", "a1", "0", "Ash", "92270642656", "0", "1", "false", "0", "0", "0", "0"},
+			rows: 10,
+		},
+		{
+			name: dissect.PlayerMatchStatsCSV,
+			header: []string{
+				"match_id", "profile_id", "username", "team_index",
+				"rounds", "kills", "deaths", "assists", "headshots", "headshot_percentage",
+			},
+			row:  []string{"match-1", "id-a1", "a1", "0", "1", "1", "0", "0", "0", "0"},
+			rows: 10,
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records := tables[i]
+			if len(records) != test.rows+1 {
+				t.Fatalf("expected %d rows, got %d", test.rows, len(records)-1)
+			}
+			if diff := deep.Equal(records[0], test.header); diff != nil {
+				t.Errorf("header: %v", diff)
+			}
+			if diff := deep.Equal(records[1], test.row); diff != nil {
+				t.Errorf("row: %v", diff)
+			}
+		})
+	}
+}
+
+func TestCSVWriter_WriteRound(t *testing.T) {
+	dir := t.TempDir()
+	w, err := dissect.CreateCSVFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := csvRound()
+	second := csvRound()
+	second.RoundNumber = 1
+	for _, data := range []dissect.RoundData{first, second} {
+		if err = w.WriteRound(data.Reader()); err != nil {
+			t.Fatalf("WriteRound(): expected no error, got %v", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rows := map[string]int{
+		// the match row is only written for the first round
+		dissect.MatchesCSV:          1,
+		dissect.RoundsCSV:           2,
+		dissect.EventsCSV:           2,
+		dissect.PlayerRoundStatsCSV: 20,
+		dissect.PlayerMatchStatsCSV: 0,
+	}
+	for name, want := range rows {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records)-1 != want {
+			t.Errorf("%s: expected %d rows, got %d", name, want, len(records)-1)
+		}
+	}
+}
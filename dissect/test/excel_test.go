@@ -0,0 +1,205 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExcelLayoutValidate(t *testing.T) {
+	sheet := func(name string, scope dissect.ExcelScope, tables ...dissect.ExcelTable) dissect.ExcelSheet {
+		return dissect.ExcelSheet{Name: name, Scope: scope, Tables: tables}
+	}
+	stats := dissect.ExcelTable{Title: "Statistics", Source: "playerMatchStats"}
+	tests := []struct {
+		name   string
+		layout dissect.ExcelLayout
+		err    string // part of the error, empty if the layout is valid
+	}{
+		{
+			name:   "default",
+			layout: dissect.DefaultExcelLayout,
+		},
+		{
+			name:   "no sheets",
+			layout: dissect.ExcelLayout{},
+			err:    "no sheets",
+		},
+		{
+			name:   "unknown scope",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{sheet("Match", "season", stats)}},
+			err:    `unknown scope "season"`,
+		},
+		{
+			name:   "duplicate sheet",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{sheet("Match", dissect.MatchScope, stats), sheet("match", dissect.OverviewScope)}},
+			err:    `excel sheet "match": duplicate sheet name`,
+		},
+		{
+			name: "unknown source",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{
+				sheet("Match", dissect.MatchScope, dissect.ExcelTable{Title: "Statistics", Source: "scoreboard"}),
+			}},
+			err: `unknown source "scoreboard"`,
+		},
+		{
+			name: "unknown column",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{
+				sheet("Match", dissect.MatchScope, dissect.ExcelTable{
+					Title:   "Statistics",
+					Source:  "playerMatchStats",
+					Columns: []dissect.ExcelColumn{{Header: "Player", Field: "username"}, {Header: "ADR", Field: "adr"}},
+				}),
+			}},
+			err: `unknown field "adr" of playerMatchStats`,
+		},
+		{
+			name: "unknown sort field",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{
+				sheet("Match", dissect.MatchScope, dissect.ExcelTable{Title: "Statistics", Source: "playerMatchStats", SortBy: "adr"}),
+			}},
+			err: `unknown field "adr"`,
+		},
+		{
+			name: "invalid position",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{
+				sheet("Match", dissect.MatchScope, dissect.ExcelTable{Title: "Statistics", Source: "playerMatchStats", Position: "1K"}),
+			}},
+			err: `excel table "Statistics"`,
+		},
+		{
+			name: "chart field that is not a column",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{
+				sheet("Match", dissect.MatchScope, dissect.ExcelTable{
+					Title:   "Rounds",
+					Source:  "rounds",
+					Columns: []dissect.ExcelColumn{{Header: "Round", Field: "round"}},
+					Charts:  []dissect.ExcelChart{{Type: "line", Title: "Score", Category: "round", Values: []string{"team0Score"}}},
+				}),
+			}},
+			err: `field "team0Score" is not a column`,
+		},
+		{
+			name: "unknown chart type",
+			layout: dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{
+				sheet("Match", dissect.MatchScope, dissect.ExcelTable{
+					Title:  "Rounds",
+					Source: "rounds",
+					Charts: []dissect.ExcelChart{{Type: "pie", Category: "round"}},
+				}),
+			}},
+			err: `unknown chart type "pie"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.layout.Validate()
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatalf("Validate(): expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Validate(): expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+// excelMatches returns two matches in which a1 is renamed to ace in the second.
+func excelMatches() []*dissect.MatchReader {
+	first := syntheticRound(0, kill("a1", "b1", 120))
+	first.MatchID = "match-1"
+	second := syntheticRound(1, kill("b1", "a2", 120))
+	second.MatchID = "match-1"
+	second.RoundNumber = 1
+	third := syntheticRound(0, kill("a1", "b1", 120), kill("a1", "b2", 110))
+	third.MatchID = "match-2"
+	third.Players[0].Username = "ace"
+	third.MatchFeedback[0].Username = "ace"
+	third.MatchFeedback[1].Username = "ace"
+	return []*dissect.MatchReader{
+		dissect.MatchData{Rounds: []dissect.RoundData{first, second}}.MatchReader(),
+		dissect.MatchData{Rounds: []dissect.RoundData{third}}.MatchReader(),
+	}
+}
+
+func TestWriteExcelMatches(t *testing.T) {
+	var b bytes.Buffer
+	if err := dissect.WriteExcelMatches(&b, excelMatches()); err != nil {
+		t.Fatalf("WriteExcelMatches(): expected no error, got %v", err)
+	}
+	f, err := excelize.OpenReader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want := []string{
+		"Overview",
+		"M1 Match", "M1 Players", "M1 Operators", "M1 Sites", "M1 Round 1", "M1 Round 2",
+		"M2 Match", "M2 Players", "M2 Operators", "M2 Sites", "M2 Round 1",
+	}
+	if diff := deep.Equal(f.GetSheetList(), want); diff != nil {
+		t.Errorf("sheets: %v", diff)
+	}
+}
+
+func TestWriteExcelMatches_Totals(t *testing.T) {
+	layout := dissect.ExcelLayout{Sheets: []dissect.ExcelSheet{{
+		Name:  "Totals",
+		Scope: dissect.OverviewScope,
+		Tables: []dissect.ExcelTable{{
+			Source: "playerTotals",
+			Columns: []dissect.ExcelColumn{
+				{Header: "Player", Field: "username"},
+				{Header: "Matches", Field: "matches"},
+				{Header: "Rounds", Field: "rounds"},
+				{Header: "Kills", Field: "kills"},
+				{Header: "Deaths", Field: "deaths"},
+			},
+		}},
+	}}}
+	var b bytes.Buffer
+	if err := dissect.WriteExcelMatchesLayout(&b, excelMatches(), layout); err != nil {
+		t.Fatalf("WriteExcelMatchesLayout(): expected no error, got %v", err)
+	}
+	f, err := excelize.OpenReader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if diff := deep.Equal(f.GetSheetList(), []string{"Totals"}); diff != nil {
+		t.Errorf("sheets: %v", diff)
+	}
+	rows, err := f.GetRows("Totals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	totals := make(map[string][]string)
+	for _, row := range rows {
+		if len(row) == 5 {
+			totals[row[0]] = row[1:]
+		}
+	}
+	want := map[string][]string{
+		"Player": {"Matches", "Rounds", "Kills", "Deaths"},
+		// a1 is grouped with ace by profile ID, under the latest username
+		"ace": {"2", "3", "3", "0"},
+		"a2":  {"2", "3", "0", "1"},
+		"b1":  {"2", "3", "1", "2"},
+		"b3":  {"2", "3", "0", "0"},
+	}
+	for player, want := range want {
+		if diff := deep.Equal(totals[player], want); diff != nil {
+			t.Errorf("%s: %v", player, diff)
+		}
+	}
+	if _, ok := totals["a1"]; ok {
+		t.Error("a1 was not grouped with ace")
+	}
+}
@@ -2,6 +2,12 @@
 
 package test
 
+import (
+	"fmt"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
 // sliceDiff returns a list of items that are in a, but not in b
 // with O(n) complexity
 func sliceDiff[T comparable](a, b []T) (diff []T) {
@@ -22,3 +28,50 @@ func sliceDiff[T comparable](a, b []T) (diff []T) {
 	}
 	return
 }
+
+// syntheticRound returns a round between team 0 (a1 to a5, attacking)
+// and team 1 (b1 to b5, defending) with the match feedback given.
+func syntheticRound(winner int, feedback ...dissect.MatchUpdate) dissect.RoundData {
+	data := dissect.RoundData{MatchFeedback: feedback}
+	data.Teams[0].Role = dissect.Attack
+	data.Teams[1].Role = dissect.Defense
+	data.Teams[winner].Won = true
+	data.Teams[winner].Score = 1
+	for team, prefix := range []string{"a", "b"} {
+		for i := 1; i <= 5; i++ {
+			username := fmt.Sprintf("%s%d", prefix, i)
+			data.Players = append(data.Players, dissect.Player{
+				ProfileID: "id-" + username,
+				Username:  username,
+				TeamIndex: team,
+			})
+		}
+	}
+	return data
+}
+
+// kill returns a kill of target by killer at seconds on the round clock.
+func kill(killer, target string, seconds float64) dissect.MatchUpdate {
+	headshot := false
+	return dissect.MatchUpdate{
+		Type:          dissect.Kill,
+		Username:      killer,
+		Target:        target,
+		Headshot:      &headshot,
+		TimeInSeconds: seconds,
+	}
+}
+
+// event returns a match update of the player at seconds on the round clock.
+func event(t dissect.MatchUpdateType, username string, seconds float64) dissect.MatchUpdate {
+	return dissect.MatchUpdate{
+		Type:          t,
+		Username:      username,
+		TimeInSeconds: seconds,
+	}
+}
+
+// pct returns n of total as a percentage, rounded the same way as the stats.
+func pct(n, total int) float64 {
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestIndexScan_Prune(t *testing.T) {
+	root := t.TempDir()
+	unreadable := filepath.Join(root, "Match-unreadable")
+	empty := filepath.Join(root, "Match-empty")
+	for _, dir := range []string{unreadable, empty} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(unreadable, "R01.rec"), []byte("replay"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "Match-outside")
+	idx := dissect.NewIndex()
+	for _, path := range []string{
+		unreadable,
+		empty,
+		filepath.Join(root, "Match-deleted"),
+		outside,
+	} {
+		idx.Matches = append(idx.Matches, dissect.IndexEntry{MatchID: filepath.Base(path), Path: path})
+	}
+	n, err := idx.Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("indexed %d folders, want 0", n)
+	}
+	paths := make([]string, 0, len(idx.Matches))
+	for _, entry := range idx.Matches {
+		paths = append(paths, entry.Path)
+	}
+	slices.Sort(paths)
+	want := []string{unreadable, outside}
+	slices.Sort(want)
+	if !slices.Equal(paths, want) {
+		t.Errorf("got entries %v, want %v", paths, want)
+	}
+}
+
+func TestIndexScan_AbsolutePaths(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	// resolve symlinks such as /tmp on macOS, the same way as os.Getwd
+	if dir, err = os.Getwd(); err != nil {
+		t.Fatal(err)
+	}
+	folder := filepath.Join("replays", "Match-unreadable")
+	if err = os.MkdirAll(folder, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(folder, "R01.rec"), []byte("replay"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx := dissect.NewIndex()
+	// written by an older version with the paths given on the command line
+	idx.Matches = append(idx.Matches,
+		dissect.IndexEntry{MatchID: "unreadable", Path: folder},
+		dissect.IndexEntry{MatchID: "deleted", Path: filepath.Join("replays", "Match-deleted")},
+	)
+	for _, root := range []string{"." + string(filepath.Separator) + "replays", "replays", filepath.Join(dir, "replays")} {
+		if _, err = idx.Scan(root); err != nil {
+			t.Fatal(err)
+		}
+		want := filepath.Join(dir, folder)
+		if len(idx.Matches) != 1 || idx.Matches[0].Path != want {
+			t.Fatalf("Scan(%q): expected a single entry for %s, got %+v", root, want, idx.Matches)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// TestReadRoundJSON validates exported rounds load back with the same stats.
+func TestReadRoundJSON(t *testing.T) {
+	t.Run("../../examples/unranked_R01.json", withFile("../../examples/unranked_R01.json", func(f *os.File, t *testing.T) {
+		r, err := dissect.ReadRoundJSON(f)
+		if err != nil {
+			t.Fatalf("ReadRoundJSON(): expected no error, got %v", err)
+		}
+		if _, err = f.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		var want struct {
+			PlayerStats []dissect.PlayerRoundStats `json:"stats"`
+		}
+		if err = json.NewDecoder(f).Decode(&want); err != nil {
+			t.Fatal(err)
+		}
+		got := r.PlayerStats()
+		if len(got) != len(want.PlayerStats) {
+			t.Fatalf("player count mismatch: got %d, want %d", len(got), len(want.PlayerStats))
+		}
+		for i, gotS := range got {
+			wantS := want.PlayerStats[i]
+			fieldsCompare := []struct {
+				name string
+				got  any
+				want any
+			}{
+				{"Username", gotS.Username, wantS.Username},
+				{"Score", gotS.Score, wantS.Score},
+				{"Kills", gotS.Kills, wantS.Kills},
+				{"Died", gotS.Died, wantS.Died},
+				{"Assists", gotS.Assists, wantS.Assists},
+				{"Headshots", gotS.Headshots, wantS.Headshots},
+				{"HeadshotPercentage", gotS.HeadshotPercentage, wantS.HeadshotPercentage},
+				{"OneVx", gotS.OneVx, wantS.OneVx},
+			}
+			for _, field := range fieldsCompare {
+				if diffs := deep.Equal(field.got, field.want); diffs != nil {
+					t.Errorf("PlayerStats[%d].%s mismatch (got, want): %v", i, field.name, diffs)
+				}
+			}
+		}
+		// exporting the loaded round again must not change it
+		var b bytes.Buffer
+		if err = r.WriteJSON(&b); err != nil {
+			t.Fatal(err)
+		}
+		m, loaded, err := dissect.ReadJSON(&b)
+		if err != nil {
+			t.Fatalf("ReadJSON(): expected no error, got %v", err)
+		}
+		if m != nil {
+			t.Fatal("ReadJSON(): expected a round, got a match")
+		}
+		if diffs := deep.Equal(loaded.Data(), r.Data()); diffs != nil {
+			t.Errorf("round trip mismatch (got, want): %v", diffs)
+		}
+	}))
+}
+
+func TestReadJSON_UnsupportedSchema(t *testing.T) {
+	in := bytes.NewBufferString(`{"schemaVersion": 999, "rounds": []}`)
+	if _, _, err := dissect.ReadJSON(in); err == nil {
+		t.Fatal("expected err, got nil")
+	}
+}
+
+func TestReadJSON_Invalid(t *testing.T) {
+	players := `"teams": [{"name": "A"}, {"name": "B"}], "players": [{"username": "a", "teamIndex": 0}, {"username": "b", "teamIndex": 1}]`
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"no players", `{"schemaVersion": 1, "teams": [{"name": "A"}, {"name": "B"}], "matchFeedback": []}`},
+		{"team index", `{"schemaVersion": 1, "players": [{"username": "a", "teamIndex": 2}], "matchFeedback": []}`},
+		{"kill without headshot", `{"schemaVersion": 1, ` + players + `, "matchFeedback": [{"type": {"name": "Kill", "id": 0}, "username": "a", "target": "b"}]}`},
+		{"kill without target", `{"schemaVersion": 1, ` + players + `, "matchFeedback": [{"type": {"name": "Kill", "id": 0}, "username": "a", "headshot": true}]}`},
+		{"match without rounds", `{"schemaVersion": 1, "rounds": []}`},
+		{"match with an invalid round", `{"schemaVersion": 1, "rounds": [{"matchFeedback": []}]}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := dissect.ReadJSON(bytes.NewBufferString(test.json))
+			if !errors.Is(err, dissect.ErrInvalidData) {
+				t.Fatalf("ReadJSON(): expected ErrInvalidData, got %v", err)
+			}
+		})
+	}
+}
+
+// TestReadJSON_OldFormat rejects exports from before RoundData instead of panicking.
+func TestReadJSON_OldFormat(t *testing.T) {
+	t.Run("data/replays/valid/Y8S1/quick_1.rec.json", withFile("data/replays/valid/Y8S1/quick_1.rec.json", func(f *os.File, t *testing.T) {
+		if _, _, err := dissect.ReadJSON(f); !errors.Is(err, dissect.ErrInvalidData) {
+			t.Fatalf("ReadJSON(): expected ErrInvalidData, got %v", err)
+		}
+	}))
+}
+
+// TestPlayerStats_UnknownPlayers ignores events of players missing from the header.
+func TestPlayerStats_UnknownPlayers(t *testing.T) {
+	headshot := true
+	data := dissect.RoundData{
+		Header: dissect.Header{Players: []dissect.Player{{Username: "a", TeamIndex: 0}, {Username: "b", TeamIndex: 1}}},
+		MatchFeedback: []dissect.MatchUpdate{
+			{Type: dissect.Kill, Username: "c", Target: "a", Headshot: &headshot},
+			{Type: dissect.Kill, Username: "b", Target: "d"},
+			{Type: dissect.Death, Username: "e"},
+		},
+	}
+	stats := data.Reader().PlayerStats()
+	if !stats[0].Died || stats[1].Kills != 1 || stats[1].Headshots != 0 {
+		t.Errorf("PlayerStats(): unexpected stats %+v", stats)
+	}
+}
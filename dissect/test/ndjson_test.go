@@ -0,0 +1,141 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// ndjsonLines decodes every line written by StreamRound or StreamMatch.
+func ndjsonLines(t *testing.T, in io.Reader) []dissect.NDJSONLine {
+	t.Helper()
+	lines := make([]dissect.NDJSONLine, 0)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var line dissect.NDJSONLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %d: %v", len(lines)+1, err)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+// lineTypes returns the type tag of each line, with runs of the same type collapsed.
+func lineTypes(lines []dissect.NDJSONLine) []dissect.NDJSONLineType {
+	types := make([]dissect.NDJSONLineType, 0)
+	for _, line := range lines {
+		if len(types) == 0 || types[len(types)-1] != line.Type {
+			types = append(types, line.Type)
+		}
+	}
+	return types
+}
+
+func TestStreamRound(t *testing.T) {
+	t.Run("../../examples/unranked_R01.json", withFile("../../examples/unranked_R01.json", func(f *os.File, t *testing.T) {
+		r, err := dissect.ReadRoundJSON(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err = dissect.StreamRound(r, &b); err != nil {
+			t.Fatalf("StreamRound(): expected no error, got %v", err)
+		}
+		lines := ndjsonLines(t, &b)
+		want := []dissect.NDJSONLineType{
+			dissect.HeaderLine,
+			dissect.PlayerLine,
+			dissect.EventLine,
+			dissect.RoundStatsLine,
+			dissect.RoundEndLine,
+		}
+		if diff := deep.Equal(lineTypes(lines), want); diff != nil {
+			t.Fatalf("line types: %v", diff)
+		}
+		header := lines[0]
+		if header.Header == nil || header.Header.MatchID != r.Header.MatchID || len(header.Header.Players) != 0 {
+			t.Errorf("header line: expected the header without players, got %+v", header.Header)
+		}
+		players := make([]dissect.Player, 0)
+		events := make([]dissect.MatchUpdate, 0)
+		stats := 0
+		for i, line := range lines {
+			if line.MatchID != r.Header.MatchID || line.Round != r.Header.RoundNumber+1 {
+				t.Errorf("line %d: expected match %s round %d, got match %s round %d",
+					i+1, r.Header.MatchID, r.Header.RoundNumber+1, line.MatchID, line.Round)
+			}
+			switch line.Type {
+			case dissect.PlayerLine:
+				players = append(players, *line.Player)
+			case dissect.EventLine:
+				events = append(events, *line.Event)
+			case dissect.RoundStatsLine:
+				stats++
+			}
+		}
+		if len(players) != len(r.Header.Players) {
+			t.Errorf("expected %d player lines, got %d", len(r.Header.Players), len(players))
+		}
+		for i, p := range players {
+			if p.Username != r.Header.Players[i].Username {
+				t.Errorf("player line %d: expected %s, got %s", i+1, r.Header.Players[i].Username, p.Username)
+			}
+		}
+		if diff := deep.Equal(events, r.MatchFeedback); diff != nil {
+			t.Errorf("events: expected the match feedback in order: %v", diff)
+		}
+		if stats != len(r.Header.Players) {
+			t.Errorf("expected %d round stats lines, got %d", len(r.Header.Players), stats)
+		}
+		end := lines[len(lines)-1]
+		if end.Teams == nil || *end.Teams != r.Header.Teams {
+			t.Errorf("round end line: expected the teams %+v, got %+v", r.Header.Teams, end.Teams)
+		}
+	}))
+}
+
+func TestStreamMatch(t *testing.T) {
+	first := syntheticRound(0, kill("a1", "b1", 120))
+	second := syntheticRound(1, kill("b1", "a1", 120))
+	second.RoundNumber = 1
+	m := dissect.MatchData{Rounds: []dissect.RoundData{first, second}}.MatchReader()
+	var b bytes.Buffer
+	if err := dissect.StreamMatch(m, &b); err != nil {
+		t.Fatalf("StreamMatch(): expected no error, got %v", err)
+	}
+	lines := ndjsonLines(t, &b)
+	round := []dissect.NDJSONLineType{
+		dissect.HeaderLine,
+		dissect.PlayerLine,
+		dissect.EventLine,
+		dissect.RoundStatsLine,
+		dissect.RoundEndLine,
+	}
+	want := append(append(round, round...), dissect.MatchStatsLine, dissect.MatchEndLine)
+	if diff := deep.Equal(lineTypes(lines), want); diff != nil {
+		t.Fatalf("line types: %v", diff)
+	}
+	rounds := make([]int, 0)
+	for _, line := range lines {
+		if line.Type == dissect.HeaderLine {
+			rounds = append(rounds, line.Round)
+		}
+		if (line.Type == dissect.MatchStatsLine || line.Type == dissect.MatchEndLine) && line.Round != 0 {
+			t.Errorf("%s line: expected no round, got %d", line.Type, line.Round)
+		}
+	}
+	if diff := deep.Equal(rounds, []int{1, 2}); diff != nil {
+		t.Errorf("header rounds: %v", diff)
+	}
+}
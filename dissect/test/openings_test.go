@@ -0,0 +1,152 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestOpeningDuel(t *testing.T) {
+	tests := []struct {
+		name     string
+		feedback []dissect.MatchUpdate
+		want     dissect.OpeningDuel
+		ok       bool
+	}{
+		{
+			name:     "kill",
+			feedback: []dissect.MatchUpdate{kill("a1", "b1", 120), kill("b2", "a1", 110)},
+			want:     dissect.OpeningDuel{Killer: "a1", KillerTeam: 0, Victim: "b1", VictimTeam: 1},
+			ok:       true,
+		},
+		{
+			name: "events before the first kill",
+			feedback: []dissect.MatchUpdate{
+				event(dissect.OperatorSwap, "a2", 170),
+				event(dissect.LocateObjective, "a3", 150),
+				kill("b2", "a1", 110),
+			},
+			want: dissect.OpeningDuel{Killer: "b2", KillerTeam: 1, Victim: "a1", VictimTeam: 0},
+			ok:   true,
+		},
+		{
+			name:     "death without a killer",
+			feedback: []dissect.MatchUpdate{event(dissect.Death, "a1", 120), kill("a2", "b1", 110)},
+			want:     dissect.OpeningDuel{KillerTeam: -1, Victim: "a1", VictimTeam: 0},
+			ok:       true,
+		},
+		{
+			name:     "team kill",
+			feedback: []dissect.MatchUpdate{kill("a2", "a1", 120)},
+			want:     dissect.OpeningDuel{KillerTeam: -1, Victim: "a1", VictimTeam: 0},
+			ok:       true,
+		},
+		{
+			name:     "nobody died",
+			feedback: []dissect.MatchUpdate{event(dissect.DefuserPlantComplete, "a1", 20)},
+			ok:       false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := syntheticRound(0, test.feedback...).Reader().OpeningDuel()
+			if ok != test.ok {
+				t.Fatalf("OpeningDuel(): expected ok %v, got %v", test.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if got != test.want {
+				t.Errorf("OpeningDuel(): expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+// openingsMatch returns a match of three rounds: team 0 wins the opening
+// duel and the round, wins the opening duel and loses the round, then
+// defends and loses the opening duel and the round.
+func openingsMatch() *dissect.MatchReader {
+	third := syntheticRound(1, kill("b1", "a1", 120))
+	third.Teams[0].Role, third.Teams[1].Role = dissect.Defense, dissect.Attack
+	return dissect.MatchData{Rounds: []dissect.RoundData{
+		syntheticRound(0, kill("a1", "b1", 120)),
+		syntheticRound(1, kill("a1", "b1", 120)),
+		third,
+	}}.MatchReader()
+}
+
+func TestTeamOpeningDuels(t *testing.T) {
+	got := openingsMatch().TeamOpeningDuels()
+	want := [2]dissect.TeamOpeningDuels{
+		{Wins: 2, Losses: 1, WinRate: pct(2, 3), RoundsWonAfterWin: 1, WinRateAfterWin: 50},
+		{Wins: 1, Losses: 2, WinRate: pct(1, 3), RoundsWonAfterWin: 1, RoundsWonAfterLoss: 1, WinRateAfterWin: 100, WinRateAfterLoss: 50},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("team %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestPlayerOpeningDuels(t *testing.T) {
+	stats := openingsMatch().PlayerStats()
+	want := map[string]dissect.PlayerOpeningDuels{
+		"a1": {
+			Attack:  dissect.OpeningDuelStats{Attempts: 2, Wins: 2, SuccessRate: 100},
+			Defense: dissect.OpeningDuelStats{Attempts: 1, Losses: 1},
+			Total:   dissect.OpeningDuelStats{Attempts: 3, Wins: 2, Losses: 1, SuccessRate: pct(2, 3)},
+		},
+		"b1": {
+			Attack:  dissect.OpeningDuelStats{Attempts: 1, Wins: 1, SuccessRate: 100},
+			Defense: dissect.OpeningDuelStats{Attempts: 2, Losses: 2},
+			Total:   dissect.OpeningDuelStats{Attempts: 3, Wins: 1, Losses: 2, SuccessRate: pct(1, 3)},
+		},
+		"a2": {},
+	}
+	for _, s := range stats {
+		w, ok := want[s.Username]
+		if !ok {
+			continue
+		}
+		if s.OpeningDuels != w {
+			t.Errorf("%s: expected %+v, got %+v", s.Username, w, s.OpeningDuels)
+		}
+	}
+}
+
+func TestReportOpeningDuel(t *testing.T) {
+	tests := []struct {
+		name     string
+		feedback []dissect.MatchUpdate
+		want     *dissect.ReportKill
+	}{
+		{
+			name:     "kill",
+			feedback: []dissect.MatchUpdate{kill("a1", "b1", 120)},
+			want:     &dissect.ReportKill{Killer: "a1", KillerTeam: 0, Target: "b1", TargetTeam: 1},
+		},
+		{
+			name:     "death before the first kill",
+			feedback: []dissect.MatchUpdate{event(dissect.Death, "a2", 130), kill("a1", "b1", 120)},
+			want:     &dissect.ReportKill{KillerTeam: -1, Target: "a2", TargetTeam: 0},
+		},
+		{
+			name:     "team kill",
+			feedback: []dissect.MatchUpdate{kill("b2", "b1", 120)},
+			want:     &dissect.ReportKill{KillerTeam: -1, Target: "b1", TargetTeam: 1},
+		},
+		{
+			name: "nobody died",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := syntheticRound(0, test.feedback...).Reader()
+			got := r.Report().Rounds[0].OpeningDuel
+			if (got == nil) != (test.want == nil) || got != nil && *got != *test.want {
+				t.Errorf("OpeningDuel: expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
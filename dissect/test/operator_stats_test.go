@@ -0,0 +1,90 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// withOperators sets the operators picked by the players of a round.
+func withOperators(data dissect.RoundData, operators map[string]dissect.Operator) dissect.RoundData {
+	for i, p := range data.Players {
+		data.Players[i].Operator = operators[p.Username]
+	}
+	return data
+}
+
+// operatorStatsMatch returns a match of two rounds where a1 picks Ash and
+// b1 picks Mute, trading the opening kill, and a2 and b2 pick Recruit.
+func operatorStatsMatch() *dissect.MatchReader {
+	operators := map[string]dissect.Operator{
+		"a1": dissect.Ash,
+		"a2": dissect.Recruit,
+		"b1": dissect.Mute,
+		"b2": dissect.Recruit,
+	}
+	return dissect.MatchData{Rounds: []dissect.RoundData{
+		withOperators(syntheticRound(0, kill("a1", "b1", 170)), operators),
+		withOperators(syntheticRound(1, kill("b1", "a1", 160)), operators),
+	}}.MatchReader()
+}
+
+func TestOperatorStats(t *testing.T) {
+	ash := dissect.OperatorRecord{
+		Picks:           2,
+		RoundsWon:       1,
+		WinRate:         50,
+		Kills:           1,
+		Deaths:          1,
+		KD:              1,
+		SurvivalRate:    50,
+		OpeningKills:    1,
+		OpeningKillRate: 50,
+	}
+	// recruits are kept apart by the side they were picked on
+	recruit := dissect.OperatorRecord{Picks: 2, RoundsWon: 1, WinRate: 50, SurvivalRate: 100}
+	want := []dissect.OperatorStats{
+		{
+			Operator:       dissect.Ash,
+			Role:           dissect.Attack,
+			OperatorRecord: ash,
+			Players:        []dissect.PlayerOperatorStats{{Username: "a1", ProfileID: "id-a1", OperatorRecord: ash}},
+		},
+		{
+			Operator:       dissect.Recruit,
+			Role:           dissect.Attack,
+			OperatorRecord: recruit,
+			Players:        []dissect.PlayerOperatorStats{{Username: "a2", ProfileID: "id-a2", OperatorRecord: recruit}},
+		},
+		{
+			Operator:       dissect.Mute,
+			Role:           dissect.Defense,
+			OperatorRecord: ash,
+			Players:        []dissect.PlayerOperatorStats{{Username: "b1", ProfileID: "id-b1", OperatorRecord: ash}},
+		},
+		{
+			Operator:       dissect.Recruit,
+			Role:           dissect.Defense,
+			OperatorRecord: recruit,
+			Players:        []dissect.PlayerOperatorStats{{Username: "b2", ProfileID: "id-b2", OperatorRecord: recruit}},
+		},
+	}
+	if diff := deep.Equal(operatorStatsMatch().OperatorStats(), want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestOperatorStatsOf(t *testing.T) {
+	got := dissect.OperatorStatsOf(operatorStatsMatch(), operatorStatsMatch())
+	if len(got) != 4 {
+		t.Fatalf("got %d operators, want 4", len(got))
+	}
+	ash := got[0]
+	if ash.Operator != dissect.Ash || ash.Picks != 4 || ash.Kills != 2 || ash.WinRate != 50 {
+		t.Errorf("Ash: got %+v", ash.OperatorRecord)
+	}
+	if len(ash.Players) != 1 || ash.Players[0].Picks != 4 {
+		t.Errorf("Ash players: got %+v, want a1 grouped by profile ID", ash.Players)
+	}
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// parquetRound returns round number (0-based) of the match with the id given.
+func parquetRound(matchID string, number int) *dissect.Reader {
+	data := syntheticRound(0, kill("a1", "b1", 170))
+	data.MatchID = matchID
+	data.RoundNumber = number
+	data.Timestamp = time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	return data.Reader()
+}
+
+func parquetRounds(t *testing.T, dir, matchID string) []int32 {
+	t.Helper()
+	path := filepath.Join(dir, dissect.PlayerRoundStatsParquet, "match="+matchID, "data.parquet")
+	rows, err := parquet.ReadFile[dissect.ParquetPlayerRound](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rounds := make([]int32, 0)
+	for _, row := range rows {
+		if len(rounds) == 0 || rounds[len(rounds)-1] != row.Round {
+			rounds = append(rounds, row.Round)
+		}
+	}
+	return rounds
+}
+
+func TestParquetWriter_WriteMatch(t *testing.T) {
+	dir := t.TempDir()
+	w := dissect.NewParquetWriter(dir, dissect.PartitionByMatch)
+	m := dissect.MatchData{Rounds: []dissect.RoundData{
+		parquetRound("a", 0).Data(),
+		parquetRound("a", 1).Data(),
+	}}.MatchReader()
+	if err := w.WriteMatch(m); err != nil {
+		t.Fatal(err)
+	}
+	// written before Close
+	if got := parquetRounds(t, dir, "a"); len(got) != 2 {
+		t.Errorf("expected rounds 1 and 2, got %v", got)
+	}
+	events, err := parquet.ReadFile[dissect.ParquetEvent](filepath.Join(dir, dissect.EventsParquet, "match=a", "data.parquet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Errorf("expected 2 events, got %d", len(events))
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParquetWriter_WriteRound(t *testing.T) {
+	dir := t.TempDir()
+	w := dissect.NewParquetWriter(dir, dissect.PartitionByMatch)
+	for _, r := range []*dissect.Reader{parquetRound("a", 0), parquetRound("a", 1), parquetRound("b", 0)} {
+		if err := w.WriteRound(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// match a is complete once a round of match b is written
+	if got := parquetRounds(t, dir, "a"); len(got) != 2 {
+		t.Errorf("match a: expected rounds 1 and 2, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, dissect.PlayerRoundStatsParquet, "match=b")); err == nil {
+		t.Error("match b: written before it was complete")
+	}
+	// a round of match a given out of order keeps the rounds already written
+	for _, r := range []*dissect.Reader{parquetRound("a", 2), parquetRound("b", 1)} {
+		if err := w.WriteRound(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := parquetRounds(t, dir, "a"); len(got) != 3 {
+		t.Errorf("match a: expected rounds 1 to 3, got %v", got)
+	}
+	if got := parquetRounds(t, dir, "b"); len(got) != 2 {
+		t.Errorf("match b: expected rounds 1 and 2, got %v", got)
+	}
+}
+
+func TestParquetWriter_PartitionByDay(t *testing.T) {
+	dir := t.TempDir()
+	w := dissect.NewParquetWriter(dir, dissect.PartitionByDay)
+	if err := w.WriteRound(parquetRound("a", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{dissect.EventsParquet, dissect.PlayerRoundStatsParquet} {
+		if _, err := os.Stat(filepath.Join(dir, table, "date=2024-05-01", "a.parquet")); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestParquetIDColumns checks that enum IDs share the INT64 type.
+func TestParquetIDColumns(t *testing.T) {
+	for _, row := range []any{dissect.ParquetEvent{}, dissect.ParquetPlayerRound{}} {
+		schema := parquet.SchemaOf(row)
+		for _, field := range schema.Fields() {
+			name := field.Name()
+			if !strings.HasSuffix(name, "_id") || field.Type().Kind() == parquet.ByteArray {
+				continue
+			}
+			if kind := field.Type().Kind(); kind != parquet.Int64 {
+				t.Errorf("%s.%s: expected INT64, got %v", schema.Name(), name, kind)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestRatingWeightsRound(t *testing.T) {
+	w := dissect.RatingWeights{
+		Base:         1,
+		Kill:         10,
+		Assist:       100,
+		Death:        -1000,
+		KOST:         1e4,
+		Survival:     1e5,
+		OpeningKill:  1e6,
+		OpeningDeath: -1e7,
+		Clutch:       1e8,
+		Plant:        1e9,
+		Defuse:       1e10,
+	}
+	tests := []struct {
+		name  string
+		stats dissect.PlayerRoundStats
+		want  float64
+	}{
+		{"no impact", dissect.PlayerRoundStats{Died: true}, 1 - 1000},
+		{"survived", dissect.PlayerRoundStats{}, 1 + 1e5},
+		{"kills and assists", dissect.PlayerRoundStats{Kills: 2, Assists: 1, Died: true, KOST: true}, 1 + 20 + 100 - 1000 + 1e4},
+		{"opening kill", dissect.PlayerRoundStats{Kills: 1, OpeningKill: true, KOST: true}, 1 + 10 + 1e4 + 1e5 + 1e6},
+		{"opening death", dissect.PlayerRoundStats{Died: true, OpeningDeath: true}, 1 - 1000 - 1e7},
+		{"won clutch", dissect.PlayerRoundStats{Kills: 3, OneVx: 3, KOST: true}, 1 + 30 + 1e4 + 1e5 + 3e8},
+		{"planted", dissect.PlayerRoundStats{Planted: true, KOST: true}, 1 + 1e4 + 1e5 + 1e9},
+		{"defused", dissect.PlayerRoundStats{Defused: true, KOST: true}, 1 + 1e4 + 1e5 + 1e10},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := w.Round(test.stats); got != test.want {
+				t.Errorf("Round(): expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultRatingWeights(t *testing.T) {
+	// 0.7 kills, 0.7 deaths, 0.3 assists and 70% KOST, without opening duels, clutches or objectives
+	w := dissect.DefaultRatingWeights
+	average := w.Base + 0.7*w.Kill + 0.3*w.Assist + 0.7*w.Death + 0.3*w.Survival + 0.7*w.KOST
+	if average < 0.9 || average > 1.1 {
+		t.Errorf("expected an average round to rate about 1.0, got %v", average)
+	}
+}
+
+func TestSetRatingWeights(t *testing.T) {
+	first := syntheticRound(0, kill("a1", "b1", 120), kill("a1", "b2", 110))
+	second := syntheticRound(1, kill("b3", "a1", 120))
+	m := dissect.MatchData{Rounds: []dissect.RoundData{first, second}}.MatchReader()
+	m.SetRatingWeights(dissect.RatingWeights{Kill: 1, Death: -1})
+	for _, s := range m.PlayerStats() {
+		if s.Username == "a1" && s.Rating != 0.5 {
+			t.Errorf("PlayerStats(): expected a1 to average (2 - 1) / 2 rounds, got %v", s.Rating)
+		}
+	}
+	r := first.Reader()
+	for _, s := range r.PlayerStats() {
+		if s.Username == "a1" && s.Rating != dissect.DefaultRatingWeights.Round(s) {
+			t.Errorf("PlayerStats(): expected the default rating, got %v", s.Rating)
+		}
+	}
+	r.SetRatingWeights(dissect.RatingWeights{Kill: 1})
+	for _, s := range r.PlayerStats() {
+		if s.Username == "a1" && s.Rating != 2 {
+			t.Errorf("PlayerStats(): expected 2 kills to rate 2, got %v", s.Rating)
+		}
+	}
+}
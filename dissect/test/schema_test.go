@@ -0,0 +1,52 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+const schemaDir = "../../schema"
+
+// TestSchemaVersion fails when the output shape changes without a SchemaVersion bump.
+func TestSchemaVersion(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join(schemaDir, "versions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions dissect.SchemaVersions
+	if err = json.Unmarshal(b, &versions); err != nil {
+		t.Fatal(err)
+	}
+	if err = dissect.CheckSchemaVersion(versions); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSchemaPublished validates the published schemas are up to date.
+func TestSchemaPublished(t *testing.T) {
+	schemas := []struct {
+		file     string
+		generate func() ([]byte, error)
+	}{
+		{"round.schema.json", dissect.RoundJSONSchema},
+		{"match.schema.json", dissect.MatchJSONSchema},
+	}
+	for _, s := range schemas {
+		want, err := s.generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(schemaDir, s.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date, run go generate ./dissect", s.file)
+		}
+	}
+}
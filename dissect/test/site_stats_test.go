@@ -0,0 +1,101 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// siteRound returns a Bomb round on Villa defended on site, where team 0
+// attacks and a1 and a2 pick the spawns given.
+func siteRound(site string, winner int, spawns [2]string, feedback ...dissect.MatchUpdate) dissect.RoundData {
+	data := syntheticRound(winner, feedback...)
+	data.GameMode = dissect.Bomb
+	data.Map = dissect.Villa
+	data.Site = site
+	data.Players[0].Spawn = spawns[0]
+	data.Players[1].Spawn = spawns[1]
+	return data
+}
+
+func TestSiteStats(t *testing.T) {
+	plant := event(dissect.DefuserPlantComplete, "a1", 30)
+	secureArea := siteRound("Dining", 0, [2]string{})
+	secureArea.GameMode = dissect.SecureArea
+	m := dissect.MatchData{Rounds: []dissect.RoundData{
+		siteRound("Aviator", 0, [2]string{"Garage", "Garage"}, plant),
+		siteRound("Aviator", 1, [2]string{"Garage", "Main"}),
+		siteRound("Trophy", 1, [2]string{}),
+		siteRound("Dining", 1, [2]string{}, plant),
+		// ignored: not Bomb, and no site
+		secureArea,
+		siteRound("", 0, [2]string{"Garage", "Garage"}),
+	}}.MatchReader()
+	want := []dissect.SiteStats{
+		{
+			Map:              dissect.Villa,
+			Site:             "Aviator",
+			Rounds:           2,
+			PickRate:         50,
+			AttackWins:       1,
+			DefenseWins:      1,
+			AttackWinRate:    50,
+			Plants:           1,
+			PlantRate:        50,
+			PostPlantWins:    1,
+			PostPlantWinRate: 100,
+			Spawns: []dissect.SpawnStats{
+				{Spawn: "Garage", Picks: 3, RoundsWon: 2, WinRate: pct(2, 3)},
+				{Spawn: "Main", Picks: 1},
+			},
+		},
+		{
+			Map:         dissect.Villa,
+			Site:        "Dining",
+			Rounds:      1,
+			PickRate:    25,
+			DefenseWins: 1,
+			Plants:      1,
+			PlantRate:   100,
+			Spawns:      []dissect.SpawnStats{},
+		},
+		{
+			// never planted, so the post-plant win rate has no plants to divide by
+			Map:         dissect.Villa,
+			Site:        "Trophy",
+			Rounds:      1,
+			PickRate:    25,
+			DefenseWins: 1,
+			Spawns:      []dissect.SpawnStats{},
+		},
+	}
+	if diff := deep.Equal(m.SiteStats(), want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSiteStats_NoDefendedSite(t *testing.T) {
+	m := dissect.MatchData{Rounds: []dissect.RoundData{siteRound("", 0, [2]string{})}}.MatchReader()
+	if got := m.SiteStats(); len(got) != 0 {
+		t.Errorf("expected no sites without a defended round, got %+v", got)
+	}
+}
+
+func TestSiteStatsOf(t *testing.T) {
+	first := dissect.MatchData{Rounds: []dissect.RoundData{siteRound("Aviator", 0, [2]string{})}}.MatchReader()
+	second := dissect.MatchData{Rounds: []dissect.RoundData{
+		siteRound("Aviator", 1, [2]string{}),
+		siteRound("Trophy", 1, [2]string{}),
+		siteRound("Trophy", 1, [2]string{}),
+	}}.MatchReader()
+	got := dissect.SiteStatsOf(first, second)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 sites, got %+v", got)
+	}
+	for _, s := range got {
+		if s.Rounds != 2 || s.PickRate != 50 {
+			t.Errorf("%s: expected 2 rounds picked 50%% of the time, got %d rounds picked %v%%", s.Site, s.Rounds, s.PickRate)
+		}
+	}
+}
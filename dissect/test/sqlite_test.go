@@ -0,0 +1,200 @@
+package test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+	_ "modernc.org/sqlite"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// sqliteRows returns the username and profile_id of every row of the table.
+func sqliteRows(t *testing.T, db *sql.DB, table string) map[string]string {
+	t.Helper()
+	rows, err := db.Query("SELECT profile_id, username FROM " + table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	players := make(map[string]string)
+	for rows.Next() {
+		var profileID, username string
+		if err = rows.Scan(&profileID, &username); err != nil {
+			t.Fatal(err)
+		}
+		players[profileID] = username
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return players
+}
+
+func TestSQLiteWriter_RenamedPlayer(t *testing.T) {
+	first := syntheticRound(0, kill("a1", "b1", 170))
+	// recorded before profile IDs
+	first.Players[9].ProfileID = ""
+	second := syntheticRound(1, kill("b1", "renamed", 170))
+	second.RoundNumber = 1
+	second.Players = append([]dissect.Player(nil), first.Players...)
+	second.Players[0].Username = "renamed"
+	m := dissect.MatchData{Rounds: []dissect.RoundData{first, second}}.MatchReader()
+	db := openSQLite(t)
+	w, err := dissect.NewSQLiteWriter(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WriteMatch(m); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"match_players", "player_match_stats"} {
+		players := sqliteRows(t, db, table)
+		if len(players) != 10 {
+			t.Errorf("%s: expected 10 players, got %v", table, players)
+		}
+		if players["id-a1"] != "renamed" {
+			t.Errorf("%s: expected id-a1 to be renamed, got %q", table, players["id-a1"])
+		}
+		if players["b5"] != "b5" {
+			t.Errorf("%s: expected the username of b5 as its profile ID, got %v", table, players)
+		}
+	}
+	var rounds []string
+	rows, err := db.Query("SELECT username FROM player_round_stats WHERE profile_id = 'id-a1' ORDER BY round")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var username string
+		if err = rows.Scan(&username); err != nil {
+			t.Fatal(err)
+		}
+		rounds = append(rounds, username)
+	}
+	if len(rounds) != 2 || rounds[0] != "a1" || rounds[1] != "renamed" {
+		t.Errorf("player_round_stats: expected a row per round for id-a1, got %v", rounds)
+	}
+}
+
+// legacyPlayerRoundStats creates player_round_stats keyed by username.
+const legacyPlayerRoundStats = `CREATE TABLE player_round_stats (
+	match_id TEXT NOT NULL,
+	round INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	profile_id TEXT NOT NULL,
+	team_index INTEGER NOT NULL,
+	operator TEXT NOT NULL,
+	score INTEGER NOT NULL,
+	kills INTEGER NOT NULL,
+	died INTEGER NOT NULL,
+	assists INTEGER NOT NULL,
+	headshots INTEGER NOT NULL,
+	headshot_percentage REAL NOT NULL,
+	one_vx INTEGER NOT NULL,
+	PRIMARY KEY (match_id, round, username)
+)`
+
+// legacyPlayerRoundStatsRows has a player renamed mid-round and one without a profile ID.
+const legacyPlayerRoundStatsRows = `INSERT INTO player_round_stats VALUES
+	('m', 1, 'old', 'id-1', 0, 'Ash', 0, 0, 0, 0, 0, 0, 0),
+	('m', 1, 'new', 'id-1', 0, 'Ash', 0, 1, 0, 0, 0, 0, 0),
+	('m', 1, 'anonymous', '', 1, 'Mute', 0, 0, 1, 0, 0, 0, 0)`
+
+// sqliteVersion returns the user_version of db.
+func sqliteVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestSQLiteWriter_Migrate(t *testing.T) {
+	db := openSQLite(t)
+	legacy := []string{
+		`CREATE TABLE match_players (
+			match_id TEXT NOT NULL,
+			username TEXT NOT NULL,
+			profile_id TEXT NOT NULL,
+			team_index INTEGER NOT NULL,
+			PRIMARY KEY (match_id, username)
+		)`,
+		`CREATE TABLE player_match_stats (
+			match_id TEXT NOT NULL,
+			username TEXT NOT NULL,
+			profile_id TEXT NOT NULL,
+			team_index INTEGER NOT NULL,
+			rounds INTEGER NOT NULL,
+			kills INTEGER NOT NULL,
+			deaths INTEGER NOT NULL,
+			assists INTEGER NOT NULL,
+			headshots INTEGER NOT NULL,
+			headshot_percentage REAL NOT NULL,
+			PRIMARY KEY (match_id, username)
+		)`,
+		`INSERT INTO match_players VALUES ('m', 'old', 'id-1', 0), ('m', 'new', 'id-1', 0), ('m', 'anonymous', '', 1)`,
+		`INSERT INTO player_match_stats VALUES
+			('m', 'old', 'id-1', 0, 1, 1, 0, 0, 0, 0),
+			('m', 'new', 'id-1', 0, 1, 2, 0, 0, 0, 0),
+			('m', 'anonymous', '', 1, 2, 0, 2, 0, 0, 0)`,
+		legacyPlayerRoundStats,
+		legacyPlayerRoundStatsRows,
+	}
+	for _, stmt := range legacy {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dissect.NewSQLiteWriter(db); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"id-1": "new", "anonymous": "anonymous"}
+	for _, table := range []string{"match_players", "player_match_stats", "player_round_stats"} {
+		players := sqliteRows(t, db, table)
+		if len(players) != len(want) || players["id-1"] != want["id-1"] || players["anonymous"] != want["anonymous"] {
+			t.Errorf("%s: expected %v, got %v", table, want, players)
+		}
+	}
+	// opening the migrated database again leaves it as is
+	if _, err := dissect.NewSQLiteWriter(db); err != nil {
+		t.Fatal(err)
+	}
+	if version := sqliteVersion(t, db); version != 2 {
+		t.Errorf("expected user_version 2, got %d", version)
+	}
+}
+
+// TestSQLiteWriter_MigrateVersion1 migrates a database whose match players
+// are already keyed by profile ID, but not its player round stats.
+func TestSQLiteWriter_MigrateVersion1(t *testing.T) {
+	db := openSQLite(t)
+	for _, stmt := range []string{legacyPlayerRoundStats, legacyPlayerRoundStatsRows, "PRAGMA user_version = 1"} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dissect.NewSQLiteWriter(db); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"id-1": "new", "anonymous": "anonymous"}
+	players := sqliteRows(t, db, "player_round_stats")
+	if len(players) != len(want) || players["id-1"] != want["id-1"] || players["anonymous"] != want["anonymous"] {
+		t.Errorf("player_round_stats: expected %v, got %v", want, players)
+	}
+	if version := sqliteVersion(t, db); version != 2 {
+		t.Errorf("expected user_version 2, got %d", version)
+	}
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// withAssists sets the assists of a player on the scoreboard of a round.
+func withAssists(data dissect.RoundData, username string, assists int) dissect.RoundData {
+	data.PlayerStats = append(data.PlayerStats, dissect.PlayerRoundStats{Username: username, Assists: assists})
+	return data
+}
+
+// matchRates are the PlayerMatchStats derived from the rounds of a player.
+type matchRates struct {
+	Rounds                                     int
+	Kills, Deaths, Assists                     int
+	KOST, KPR, DPR, APR, SurvivalRate          float64
+	TwoKills, ThreeKills, FourKills, FiveKills int
+	TradedDeaths, UntradedDeaths               int
+}
+
+func TestPlayerMatchStats(t *testing.T) {
+	// a1 dies to b1, who is refragged by a2
+	traded := syntheticRound(1, kill("b1", "a1", 120), kill("a2", "b1", 118))
+	untraded := syntheticRound(1, kill("b1", "a1", 120))
+	assisted := withAssists(syntheticRound(1, kill("b1", "a1", 120)), "a1", 1)
+	twoKills := syntheticRound(0, kill("a1", "b1", 120), kill("a1", "b2", 110))
+	threeKills := syntheticRound(0, kill("a1", "b1", 120), kill("a1", "b2", 110), kill("a1", "b3", 100))
+	fourKills := syntheticRound(1,
+		kill("a1", "b1", 120), kill("a1", "b2", 110), kill("a1", "b3", 100), kill("a1", "b4", 90),
+		kill("b5", "a1", 80),
+	)
+	fiveKills := syntheticRound(0,
+		kill("a1", "b1", 120), kill("a1", "b2", 110), kill("a1", "b3", 100), kill("a1", "b4", 90), kill("a1", "b5", 80),
+	)
+	tests := []struct {
+		name   string
+		rounds []dissect.RoundData
+		want   matchRates
+	}{
+		{
+			name:   "traded without a kill, assist or survival",
+			rounds: []dissect.RoundData{traded},
+			want:   matchRates{Rounds: 1, Deaths: 1, KOST: 100, DPR: 1, TradedDeaths: 1},
+		},
+		{
+			name:   "untraded death",
+			rounds: []dissect.RoundData{untraded},
+			want:   matchRates{Rounds: 1, Deaths: 1, DPR: 1, UntradedDeaths: 1},
+		},
+		{
+			name:   "assist without a kill or survival",
+			rounds: []dissect.RoundData{assisted},
+			want:   matchRates{Rounds: 1, Deaths: 1, Assists: 1, DPR: 1, APR: 1, UntradedDeaths: 1},
+		},
+		{
+			name:   "multi-kills",
+			rounds: []dissect.RoundData{twoKills, threeKills, fourKills, fiveKills},
+			want: matchRates{
+				Rounds: 4, Kills: 14, Deaths: 1,
+				KOST: 100, KPR: 3.5, DPR: 0.25, SurvivalRate: 75,
+				TwoKills: 1, ThreeKills: 1, FourKills: 1, FiveKills: 1,
+				UntradedDeaths: 1,
+			},
+		},
+		{
+			name:   "every round",
+			rounds: []dissect.RoundData{twoKills, traded, threeKills, fourKills, assisted, fiveKills},
+			want: matchRates{
+				Rounds: 6, Kills: 14, Deaths: 3, Assists: 1,
+				KOST: pct(5, 6), KPR: 14.0 / 6, DPR: 0.5, APR: 1.0 / 6, SurvivalRate: 50,
+				TwoKills: 1, ThreeKills: 1, FourKills: 1, FiveKills: 1,
+				TradedDeaths: 1, UntradedDeaths: 2,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := dissect.MatchData{Rounds: test.rounds}.MatchReader()
+			var got *dissect.PlayerMatchStats
+			for _, s := range m.PlayerStats() {
+				if s.Username == "a1" {
+					got = &s
+				}
+			}
+			if got == nil {
+				t.Fatal("PlayerStats(): a1 is missing")
+			}
+			rates := matchRates{
+				Rounds:         got.Rounds,
+				Kills:          got.Kills,
+				Deaths:         got.Deaths,
+				Assists:        got.Assists,
+				KOST:           got.KOST,
+				KPR:            got.KPR,
+				DPR:            got.DPR,
+				APR:            got.APR,
+				SurvivalRate:   got.SurvivalRate,
+				TwoKills:       got.TwoKills,
+				ThreeKills:     got.ThreeKills,
+				FourKills:      got.FourKills,
+				FiveKills:      got.FiveKills,
+				TradedDeaths:   got.TradedDeaths,
+				UntradedDeaths: got.UntradedDeaths,
+			}
+			if diff := deep.Equal(rates, test.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// teamStatsMatch returns a match of three rounds:
+//   - team 0 attacks, gets the first kill 10 seconds in, plants and wins
+//   - team 0 attacks, team 1 gets the first kill 20 seconds in and wins
+//   - team 0 defends the defuser planted 80 seconds in and retakes with a kill 5 seconds after the plant
+func teamStatsMatch() *dissect.MatchReader {
+	first := syntheticRound(0,
+		kill("a1", "b1", 170),
+		event(dissect.DefuserPlantComplete, "a2", 30),
+	)
+	first.Teams[0].WinCondition = dissect.DefusedBomb
+	second := syntheticRound(1, kill("b1", "a1", 160))
+	second.Teams[1].WinCondition = dissect.KilledOpponents
+	third := syntheticRound(0,
+		event(dissect.DefuserPlantComplete, "b2", 100),
+		kill("a1", "b3", 40),
+	)
+	third.Teams[0].Role, third.Teams[1].Role = dissect.Defense, dissect.Attack
+	third.Teams[0].WinCondition = dissect.DisabledDefuser
+	return dissect.MatchData{Rounds: []dissect.RoundData{first, second, third}}.MatchReader()
+}
+
+func TestTeamStats(t *testing.T) {
+	got := teamStatsMatch().TeamStats()
+	want := [2]dissect.TeamMatchStats{
+		{
+			Rounds:                 3,
+			RoundsWon:              2,
+			AttackRounds:           2,
+			AttackWins:             1,
+			AttackWinRate:          50,
+			DefenseRounds:          1,
+			DefenseWins:            1,
+			DefenseWinRate:         100,
+			WinConditions:          map[dissect.WinCondition]int{dissect.DefusedBomb: 1, dissect.DisabledDefuser: 1},
+			Plants:                 1,
+			PlantWins:              1,
+			PlantConversion:        100,
+			Retakes:                1,
+			RetakeWins:             1,
+			RetakeSuccessRate:      100,
+			OpeningDuelWins:        2,
+			OpeningDuelLosses:      1,
+			OpeningDuelWinRate:     pct(2, 3),
+			AverageFirstKill:       (10 + 85) / 2.0,
+			ManAdvantageRounds:     2,
+			ManAdvantageWins:       2,
+			ManAdvantageConversion: 100,
+			FirstAdvantageRounds:   2,
+			FirstAdvantageWins:     2,
+			FirstAdvantageWinRate:  100,
+			FirstDeficitRounds:     1,
+		},
+		{
+			Rounds:                 3,
+			RoundsWon:              1,
+			AttackRounds:           1,
+			DefenseRounds:          2,
+			DefenseWins:            1,
+			DefenseWinRate:         50,
+			WinConditions:          map[dissect.WinCondition]int{dissect.KilledOpponents: 1},
+			Plants:                 1,
+			Retakes:                1,
+			OpeningDuelWins:        1,
+			OpeningDuelLosses:      2,
+			OpeningDuelWinRate:     pct(1, 3),
+			AverageFirstKill:       20,
+			ManAdvantageRounds:     1,
+			ManAdvantageWins:       1,
+			ManAdvantageConversion: 100,
+			FirstAdvantageRounds:   1,
+			FirstAdvantageWins:     1,
+			FirstAdvantageWinRate:  100,
+			FirstDeficitRounds:     2,
+		},
+	}
+	for i := range want {
+		if diff := deep.Equal(got[i], want[i]); diff != nil {
+			t.Errorf("team %d: %v", i, diff)
+		}
+	}
+}
+
+func TestTeamStats_OpeningDuels(t *testing.T) {
+	m := teamStatsMatch()
+	stats, duels := m.TeamStats(), m.TeamOpeningDuels()
+	for i := range stats {
+		if stats[i].OpeningDuelWins != duels[i].Wins || stats[i].OpeningDuelLosses != duels[i].Losses || stats[i].OpeningDuelWinRate != duels[i].WinRate {
+			t.Errorf("team %d: team stats %+v do not match the opening duels %+v", i, stats[i], duels[i])
+		}
+	}
+}
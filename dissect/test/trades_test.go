@@ -0,0 +1,158 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+func TestTradesWithin(t *testing.T) {
+	tests := []struct {
+		name     string
+		feedback []dissect.MatchUpdate
+		window   float64
+		want     []dissect.Trade
+	}{
+		{
+			name:     "refrag",
+			feedback: []dissect.MatchUpdate{kill("a1", "b1", 120), kill("b2", "a1", 117)},
+			window:   5,
+			want:     []dissect.Trade{{Traded: "b1", Trader: "b2", Killer: "a1", Seconds: 3}},
+		},
+		{
+			name:     "outside the window",
+			feedback: []dissect.MatchUpdate{kill("a1", "b1", 120), kill("b2", "a1", 114)},
+			window:   5,
+			want:     []dissect.Trade{},
+		},
+		{
+			name:     "on the window boundary",
+			feedback: []dissect.MatchUpdate{kill("a1", "b1", 120), kill("b2", "a1", 115)},
+			window:   5,
+			want:     []dissect.Trade{{Traded: "b1", Trader: "b2", Killer: "a1", Seconds: 5}},
+		},
+		{
+			name: "unrelated events between the kills",
+			feedback: []dissect.MatchUpdate{
+				kill("a1", "b1", 120),
+				kill("a2", "b3", 119),
+				event(dissect.OperatorSwap, "b4", 118),
+				kill("b2", "a1", 117),
+			},
+			window: 5,
+			want:   []dissect.Trade{{Traded: "b1", Trader: "b2", Killer: "a1", Seconds: 3}},
+		},
+		{
+			name: "two victims of the killer",
+			feedback: []dissect.MatchUpdate{
+				kill("a1", "b1", 120),
+				kill("a1", "b2", 118),
+				kill("b3", "a1", 117),
+			},
+			window: 5,
+			want: []dissect.Trade{
+				{Traded: "b2", Trader: "b3", Killer: "a1", Seconds: 1},
+				{Traded: "b1", Trader: "b3", Killer: "a1", Seconds: 3},
+			},
+		},
+		{
+			name: "one victim of the killer outside the window",
+			feedback: []dissect.MatchUpdate{
+				kill("a1", "b1", 120),
+				kill("a1", "b2", 116),
+				kill("b3", "a1", 114),
+			},
+			window: 5,
+			want:   []dissect.Trade{{Traded: "b2", Trader: "b3", Killer: "a1", Seconds: 2}},
+		},
+		{
+			name:     "team kill",
+			feedback: []dissect.MatchUpdate{kill("a1", "b1", 120), kill("a2", "a1", 118)},
+			window:   5,
+			want:     []dissect.Trade{},
+		},
+		{
+			name:     "team killed player",
+			feedback: []dissect.MatchUpdate{kill("b1", "b2", 120), kill("b3", "b1", 118)},
+			window:   5,
+			want:     []dissect.Trade{},
+		},
+		{
+			name: "clock restarts after the plant",
+			feedback: []dissect.MatchUpdate{
+				event(dissect.DefuserPlantComplete, "a2", 10),
+				kill("a1", "b1", 44),
+				kill("b2", "a1", 40),
+			},
+			window: 5,
+			want:   []dissect.Trade{{Traded: "b1", Trader: "b2", Killer: "a1", Seconds: 4}},
+		},
+		{
+			name: "plant between the kills",
+			feedback: []dissect.MatchUpdate{
+				kill("b1", "a1", 12),
+				event(dissect.DefuserPlantComplete, "a2", 10),
+				kill("a3", "b1", 44),
+			},
+			window: 5,
+			want:   []dissect.Trade{{Traded: "a1", Trader: "a3", Killer: "b1", Seconds: 3}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := syntheticRound(0, test.feedback...).Reader()
+			got := r.TradesWithin(test.window)
+			if len(got) != len(test.want) {
+				t.Fatalf("TradesWithin(): expected %d trades, got %+v", len(test.want), got)
+			}
+			for i, want := range test.want {
+				g := got[i]
+				if g.Traded != want.Traded || g.Trader != want.Trader || g.Killer != want.Killer || g.Seconds != want.Seconds {
+					t.Errorf("trade %d: expected %+v, got %+v", i, want, g)
+				}
+			}
+		})
+	}
+}
+
+func TestSetTradeWindow(t *testing.T) {
+	r := syntheticRound(0, kill("a1", "b1", 120), kill("b2", "a1", 113)).Reader()
+	if r.TradeWindow() != dissect.DefaultTradeWindow {
+		t.Fatalf("TradeWindow(): expected %v, got %v", dissect.DefaultTradeWindow, r.TradeWindow())
+	}
+	if traded(r, "b1") {
+		t.Error("PlayerStats(): b1 was traded outside the default window")
+	}
+	r.SetTradeWindow(10)
+	if !traded(r, "b1") {
+		t.Error("PlayerStats(): b1 was not traded within the window set")
+	}
+	if pairs := r.Trades(); len(pairs) != 1 || pairs[0][0].Target != "b1" || pairs[0][1].Target != "a1" {
+		t.Errorf("Trades(): expected the kill and refrag of b1, got %+v", pairs)
+	}
+}
+
+func TestTradesWithin_Stats(t *testing.T) {
+	r := syntheticRound(1, kill("a1", "b1", 120), kill("a1", "b2", 118), kill("b3", "a1", 117)).Reader()
+	for _, s := range r.PlayerStats() {
+		switch s.Username {
+		case "b1", "b2":
+			if !s.Traded {
+				t.Errorf("PlayerStats(): %s was not traded", s.Username)
+			}
+		case "b3":
+			if s.TradeKills != 1 {
+				t.Errorf("PlayerStats(): expected 1 trade kill for b3, got %d", s.TradeKills)
+			}
+		}
+	}
+}
+
+func traded(r *dissect.Reader, username string) bool {
+	for _, s := range r.PlayerStats() {
+		if s.Username == username {
+			return s.Traded
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package dissect
+
+// DefaultTradeWindow is the number of seconds after a death in which
+// killing the killer counts as a trade, unless set with SetTradeWindow.
+const DefaultTradeWindow = 5.0
+
+// defuserTimer is the number of seconds on the clock after a defuser is planted.
+const defuserTimer = 45
+
+// Trade is a refrag: Trader killed the player who killed Traded,
+// a teammate of Trader, within the trade window.
+type Trade struct {
+	Traded  string      `json:"traded"`
+	Trader  string      `json:"trader"`
+	Killer  string      `json:"killer"`
+	Kill    MatchUpdate `json:"kill"`    // Killer killing Traded
+	Refrag  MatchUpdate `json:"refrag"`  // Trader killing Killer
+	Seconds float64     `json:"seconds"` // between the kill and the refrag
+}
+
+// Trades returns KILL MatchUpdate pairs of trades within the trade window:
+// the kill of the traded player, then the refrag.
+//
+// Deprecated: use TradesWithin, which also returns the players and the time between the kills.
+func (r *Reader) Trades() [][]MatchUpdate {
+	trades := r.TradesWithin(r.TradeWindow())
+	pairs := make([][]MatchUpdate, len(trades))
+	for i, trade := range trades {
+		pairs[i] = []MatchUpdate{trade.Kill, trade.Refrag}
+	}
+	return pairs
+}
+
+// SetTradeWindow sets the seconds after a death in which killing the killer
+// counts as a trade in the stats of the round. Zero uses DefaultTradeWindow.
+func (r *Reader) SetTradeWindow(seconds float64) {
+	r.tradeWindow = seconds
+}
+
+// TradeWindow returns the trade window used by the stats of the round.
+func (r *Reader) TradeWindow() float64 {
+	if r.tradeWindow == 0 {
+		return DefaultTradeWindow
+	}
+	return r.tradeWindow
+}
+
+// SetTradeWindow sets the trade window of every round, see Reader.SetTradeWindow.
+func (m *MatchReader) SetTradeWindow(seconds float64) {
+	m.tradeWindow = seconds
+	for _, r := range m.rounds {
+		if r != nil {
+			r.SetTradeWindow(seconds)
+		}
+	}
+}
+
+// TradesWithin returns the trades of the round within window seconds.
+// Each kill is compared to every earlier death in the window, so trades
+// are found across unrelated events, and a refrag trades every death
+// caused by its victim in the window. Team kills are not trades.
+func (r *Reader) TradesWithin(window float64) []Trade {
+	trades := make([]Trade, 0)
+	elapsed := r.elapsed()
+	traded := make(map[int]bool)
+	for i, refrag := range r.MatchFeedback {
+		if !r.opponentKill(refrag) {
+			continue
+		}
+		// the kills by the victim of the refrag, most recent first
+		for j := i - 1; j >= 0; j-- {
+			if elapsed[i]-elapsed[j] > window {
+				break
+			}
+			kill := r.MatchFeedback[j]
+			if traded[j] || !r.opponentKill(kill) || kill.Username != refrag.Target {
+				continue
+			}
+			if r.teamIndex(kill.Target) != r.teamIndex(refrag.Username) {
+				continue
+			}
+			traded[j] = true
+			trades = append(trades, Trade{
+				Traded:  kill.Target,
+				Trader:  refrag.Username,
+				Killer:  kill.Username,
+				Kill:    kill,
+				Refrag:  refrag,
+				Seconds: elapsed[i] - elapsed[j],
+			})
+		}
+	}
+	return trades
+}
+
+// opponentKill returns true if u is a kill of a player on the other team.
+func (r *Reader) opponentKill(u MatchUpdate) bool {
+	if u.Type != Kill {
+		return false
+	}
+	killer, target := r.teamIndex(u.Username), r.teamIndex(u.Target)
+	return killer >= 0 && target >= 0 && killer != target
+}
+
+// elapsed returns the seconds from the first match feedback to each match
+// feedback. The clock counts down and restarts at the defuser timer when
+// a defuser is planted.
+func (r *Reader) elapsed() []float64 {
+	elapsed := make([]float64, len(r.MatchFeedback))
+	for i := 1; i < len(r.MatchFeedback); i++ {
+		previous := r.MatchFeedback[i-1]
+		clock := previous.TimeInSeconds
+		if previous.Type == DefuserPlantComplete {
+			clock = defuserTimer
+		}
+		elapsed[i] = elapsed[i-1] + max(clock-r.MatchFeedback[i].TimeInSeconds, 0)
+	}
+	return elapsed
+}
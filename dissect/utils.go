@@ -192,3 +192,18 @@ func (c *excelCompass) Float(n float64, precision int) *excelCompass {
 	c.f.SetCellFloat(c.s, c.Cell(), n, precision, 64)
 	return c
 }
+
+// Value writes a string, bool, int or float64. Other values leave the cell empty.
+func (c *excelCompass) Value(v any) *excelCompass {
+	switch v := v.(type) {
+	case string:
+		return c.Str(v)
+	case bool:
+		return c.Bool(v)
+	case int:
+		return c.Int(v)
+	case float64:
+		return c.Float(v, 3)
+	}
+	return c
+}
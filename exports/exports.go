@@ -77,16 +77,7 @@ func dissect_read(input *C.char) *C.char {
 			res := convertErrorForExport(err)
 			return C.CString(res)
 		}
-		type round struct {
-			dissect.Header
-			MatchFeedback []dissect.MatchUpdate      `json:"matchFeedback"`
-			PlayerStats   []dissect.PlayerRoundStats `json:"stats"`
-		}
-		res := convertForExport(round{
-			r.Header,
-			r.MatchFeedback,
-			r.PlayerStats(),
-		})
+		res := convertForExport(r.Data())
 		return C.CString(res)
 	}
 }
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/redraskal/r6-dissect/dissect"
+)
+
+// input is a replay file, match folder, exported JSON file or stdin (empty path).
+type input struct {
+	path string
+}
+
+func (in input) String() string {
+	if in.stdin() {
+		return "stdin"
+	}
+	return in.path
+}
+
+func (in input) stdin() bool {
+	return len(in.path) == 0
+}
+
+func (in input) open() (*os.File, error) {
+	if in.stdin() {
+		return os.Stdin, nil
+	}
+	return os.Open(in.path)
+}
+
+func (in input) isDir() (bool, error) {
+	if in.stdin() {
+		return false, nil
+	}
+	stat, err := os.Stat(in.path)
+	if err != nil {
+		return false, err
+	}
+	return stat.IsDir(), nil
+}
+
+// resolveInputs expands glob patterns in args. Stdin is read
+// when - is specified, or when no args are given and stdin is piped.
+func resolveInputs(args []string, mode inputMode) ([]input, error) {
+	inputs := make([]input, 0, len(args))
+	for _, arg := range args {
+		if arg == "-" {
+			if mode != replayInputs {
+				return nil, newUsageError("stdin is not a valid input")
+			}
+			inputs = append(inputs, input{})
+			continue
+		}
+		if !strings.ContainsAny(arg, "*?[") {
+			inputs = append(inputs, input{arg})
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, newUsageError("invalid pattern %q: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, newUsageError("no files match %q", arg)
+		}
+		for _, match := range matches {
+			inputs = append(inputs, input{match})
+		}
+	}
+	switch mode {
+	case noInputs:
+		if len(inputs) > 0 {
+			return nil, newUsageError("unexpected arguments %v", args)
+		}
+	case singleInput:
+		if len(inputs) != 1 {
+			return nil, newUsageError("specify a single folder path")
+		}
+	case replayInputs:
+		if len(inputs) == 0 && piped(os.Stdin) {
+			inputs = append(inputs, input{})
+		}
+		if len(inputs) == 0 {
+			return nil, newUsageError("specify a valid match replay file/folder path (*.rec files)")
+		}
+	}
+	return inputs, nil
+}
+
+// isJSON is true for rounds and matches previously exported as JSON.
+func (in input) isJSON() bool {
+	return strings.EqualFold(filepath.Ext(in.path), ".json")
+}
+
+// readInput reads every round of a match folder (m) or a single round file (r).
+func readInput(in input) (m *dissect.MatchReader, r *dissect.Reader, err error) {
+	f, err := in.open()
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if in.isJSON() {
+		m, r, err = dissect.ReadJSON(f)
+		if err != nil {
+			return
+		}
+		if m != nil {
+			cliStats.match(m)
+		} else {
+			cliStats.round(r)
+		}
+		return
+	}
+	dir, err := in.isDir()
+	if err != nil {
+		return
+	}
+	if dir {
+		m, err = dissect.NewMatchReader(f)
+		if err != nil {
+			return
+		}
+		cliStats.match(m)
+		if err = m.Read(); !dissect.Ok(err) {
+			return
+		}
+		return m, nil, nil
+	}
+	r, err = dissect.NewReader(f)
+	if err != nil {
+		return
+	}
+	cliStats.round(r)
+	if err = r.Read(); !dissect.Ok(err) {
+		return
+	}
+	return nil, r, nil
+}
@@ -1,13 +1,13 @@
 package main
 
 import (
-	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/redraskal/r6-dissect/dissect"
-
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
@@ -19,172 +19,334 @@ var Version = "dev"
 type OutputFormat = string
 
 const (
-	JSON  OutputFormat = "json"
-	Excel OutputFormat = "excel"
+	JSON     OutputFormat = "json"
+	Excel    OutputFormat = "excel"
+	CSV      OutputFormat = "csv"
+	SQLite   OutputFormat = "sqlite"
+	Parquet  OutputFormat = "parquet"
+	NDJSON   OutputFormat = "ndjson"
+	HTML     OutputFormat = "html"
+	Markdown OutputFormat = "md"
 )
 
-func main() {
-	setup()
-	format := viper.GetString("format")
-	in, err := viperFileOrDefault("input", os.Stdin, os.O_RDONLY)
-	if err != nil {
-		log.Fatal().Err(err).Send()
-	}
-	defer in.Close()
-	out, err := viperFileOrDefault("output", os.Stdout, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
-	if err != nil {
-		log.Fatal().Err(err).Send()
-	}
-	defer out.Close()
-	stat, err := in.Stat()
-	if err != nil {
-		log.Fatal().Err(err).Send()
+var outputFormats = []OutputFormat{JSON, NDJSON, Excel, CSV, SQLite, Parquet, HTML, Markdown}
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1 // a replay could not be read or written
+	exitUsage = 2 // invalid command, flags or inputs
+)
+
+type inputMode int
+
+const (
+	noInputs       inputMode = iota
+	optionalInputs           // zero or more paths
+	singleInput              // exactly one path
+	replayInputs             // one or more replay files/match folders, or stdin
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	inputs  inputMode
+	level   zerolog.Level // default log level
+	flags   func(fs *pflag.FlagSet)
+	run     func(inputs []input) error
+}
+
+// usageError marks errors caused by invalid user input.
+type usageError struct {
+	error
+}
+
+func newUsageError(format string, a ...any) error {
+	return usageError{fmt.Errorf(format, a...)}
+}
+
+// defaultCommand runs when the first argument is not a command,
+// which keeps `r6-dissect <input> -o <output>` working.
+const defaultCommand = "export"
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:    "info",
+			args:    "<inputs...>",
+			summary: "prints the replay header",
+			inputs:  replayInputs,
+			level:   zerolog.InfoLevel,
+			run:     runInfo,
+		},
+		{
+			name:    "export",
+			args:    "<inputs...>",
+			summary: "exports rounds and matches to JSON, Excel, CSV, SQLite, Parquet or HTML/Markdown reports",
+			inputs:  replayInputs,
+			level:   zerolog.ErrorLevel,
+			flags: func(fs *pflag.FlagSet) {
+				formatFlags(fs)
+				fs.StringP("output-dir", "O", "", "writes one output file per input to the directory")
+				fs.IntP("parallel", "j", 1, "number of inputs to export at once")
+				// deprecated single command flags
+				fs.Bool("info", false, "prints the replay header")
+				fs.BoolP("dump", "p", false, "dumps decompressed replay to the output")
+				fs.MarkDeprecated("info", "use the info command instead")
+				fs.MarkDeprecated("dump", "use the dump command instead")
+			},
+			run: runExport,
+		},
+		{
+			name:    "dump",
+			args:    "<inputs...>",
+			summary: "dumps decompressed replay files",
+			inputs:  replayInputs,
+			level:   zerolog.ErrorLevel,
+			flags:   outputFlags,
+			run:     runDump,
+		},
+		{
+			name:    "stats",
+			args:    "<inputs...>",
+			summary: "prints player statistics as JSON",
+			inputs:  replayInputs,
+			level:   zerolog.ErrorLevel,
+			flags:   outputFlags,
+			run:     runStats,
+		},
+		{
+			name:    "events",
+			args:    "<inputs...>",
+			summary: "prints match feedback (kills, plants, ...) as JSON",
+			inputs:  replayInputs,
+			level:   zerolog.ErrorLevel,
+			flags: func(fs *pflag.FlagSet) {
+				outputFlags(fs)
+				fs.StringSlice("type", nil, "only prints events of the specified types (Kill, Death, ...)")
+			},
+			run: runEvents,
+		},
+		{
+			name:    "players",
+			args:    "<inputs...>",
+			summary: "prints the players as JSON",
+			inputs:  replayInputs,
+			level:   zerolog.ErrorLevel,
+			flags:   outputFlags,
+			run:     runPlayers,
+		},
+		{
+			name:    "index",
+			args:    "[folders...]",
+			summary: "scans folders for matches into an index file and queries it",
+			inputs:  optionalInputs,
+			level:   zerolog.InfoLevel,
+			flags: func(fs *pflag.FlagSet) {
+				outputFlags(fs)
+				fs.StringP("index", "i", "r6-dissect-index.json", "specifies the index file")
+				fs.String("map", "", "filters indexed matches by map")
+				fs.String("match-type", "", "filters indexed matches by match type")
+				fs.String("game-mode", "", "filters indexed matches by game mode")
+				fs.String("player", "", "filters indexed matches by player username or profile id")
+				fs.String("since", "", "filters indexed matches played on or after a date (YYYY-MM-DD)")
+				fs.String("until", "", "filters indexed matches played before a date (YYYY-MM-DD)")
+				fs.Bool("career", false, "prints the career stats of the players in the filtered matches instead of the matches")
+			},
+			run: runIndex,
+		},
+		{
+			name:    "watch",
+			args:    "<replay folder>",
+			summary: "exports new rounds and matches to the output directory as they are recorded",
+			inputs:  singleInput,
+			level:   zerolog.InfoLevel,
+			flags: func(fs *pflag.FlagSet) {
+				formatFlags(fs)
+				fs.Duration("settle", defaultSettle, "time a round file must stay unchanged before it is exported")
+			},
+			run: runWatch,
+		},
+		{
+			name:    "serve",
+			summary: "serves the JSON output over HTTP",
+			inputs:  noInputs,
+			level:   zerolog.InfoLevel,
+			flags:   serveFlags,
+			run:     runServe,
+		},
 	}
-	if viper.GetBool("info") {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-		if err := printHead(in); err != nil {
-			log.Fatal().Err(err).Send()
+}
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	c := findCommand(defaultCommand)
+	if len(args) > 0 {
+		if args[0] == "help" {
+			return help(args[1:])
 		}
-		return
-	}
-	if viper.GetBool("dump") && stat.IsDir() {
-		log.Fatal().Msg("dump requires a replay file input.")
-	}
-	if viper.GetBool("dump") {
-		if err := writeRoundDump(in, out); err != nil {
-			log.Fatal().Err(err).Send()
+		if found := findCommand(args[0]); found != nil {
+			c = found
+			args = args[1:]
 		}
-		return
 	}
-	if stat.IsDir() {
-		if err := writeMatch(in, format, out); err != nil {
-			log.Fatal().Err(err).Send()
-		}
-		return
+	fs := pflag.NewFlagSet(c.name, pflag.ContinueOnError)
+	fs.SortFlags = false
+	if c.flags != nil {
+		c.flags(fs)
 	}
-	if format == Excel {
-		log.Fatal().Msg("Dissect will only export a match folder to Excel.")
+	fs.BoolP("debug", "d", false, "sets log level to debug")
+	fs.BoolP("version", "v", false, "prints the version")
+	fs.Float64("trade-window", dissect.DefaultTradeWindow, "seconds after a death in which killing the killer counts as a trade")
+	fs.String("rating-weights", "", "YAML/JSON file of player rating weights, see dissect.RatingWeights")
+	fs.Usage = func() {
+		printUsage(c, fs)
 	}
-	if err = writeRound(in, out); err != nil {
-		log.Fatal().Err(err).Send()
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
 	}
-}
-
-func setup() {
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-	pflag.StringP("format", "f", "", "specifies the output format (json, excel)")
-	pflag.StringP("output", "o", "", "specifies the output path")
-	pflag.BoolP("debug", "d", false, "sets log level to debug")
-	pflag.BoolP("dump", "p", false, "dumps decompressed replay to the output")
-	pflag.Bool("info", false, "prints the replay header")
-	pflag.BoolP("version", "v", false, "prints the version")
-	pflag.Parse()
-	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
-		log.Fatal().Err(err)
+	if err := viper.BindPFlags(fs); err != nil {
+		log.Error().Err(err).Send()
+		return exitError
 	}
+	zerolog.SetGlobalLevel(c.level)
 	if viper.GetBool("debug") {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
 	}
 	if viper.GetBool("version") {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 		log.Info().Msgf("r6-dissect version: %s", Version)
 		log.Info().Msg("https://github.com/redraskal/r6-dissect")
-		os.Exit(0)
+		return exitOK
 	}
-	extra := len(pflag.Args())
-	if extra < 1 && !piped(os.Stdin) {
-		log.Fatal().Msg("Specify a valid match replay file/folder path (*.rec files)")
-	} else if extra > 0 {
-		viper.Set("input", pflag.Args()[0])
+	var err error
+	if cliStats, err = loadStatsOptions(); err != nil {
+		log.Error().Err(err).Msg("could not load the rating weights")
+		return exitUsage
 	}
-	if !viper.IsSet("format") {
-		output := viper.GetString("output")
-		if strings.HasSuffix(output, ".xlsx") {
-			viper.Set("format", "excel")
-		} else if strings.HasSuffix(output, ".json") {
-			viper.Set("format", "json")
-		}
+	inputs, err := resolveInputs(fs.Args(), c.inputs)
+	if err == nil {
+		err = c.run(inputs)
 	}
-	format := strings.ToLower(viper.GetString("format"))
-	if len(format) > 0 && !(format == "json" || format == "excel") {
-		log.Fatal().Msg("Specify a valid output format (json, excel)")
-	} else if len(format) == 0 {
-		viper.Set("format", "json")
+	var usage usageError
+	if errors.As(err, &usage) {
+		log.Error().Msg(usage.Error())
+		fmt.Fprintf(os.Stderr, "Run 'r6-dissect help %s' for usage.\n", c.name)
+		return exitUsage
 	}
-}
-
-func printHead(in *os.File) error {
-	stat, err := in.Stat()
 	if err != nil {
-		return err
+		log.Error().Err(err).Send()
+		return exitError
 	}
-	if stat.IsDir() {
-		m, err := dissect.NewMatchReader(in)
-		if err != nil {
-			return err
-		}
-		r, err := m.FirstRound()
-		if err != nil {
-			return err
+	return exitOK
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
 		}
-		r.Head()
-		return nil
-	}
-	r, err := dissect.NewReader(in)
-	if err != nil {
-		return err
-	}
-	if err := r.ReadPartial(); !dissect.Ok(err) {
-		return err
 	}
-	r.Head()
 	return nil
 }
 
-func writeMatch(in *os.File, format OutputFormat, out io.Writer) error {
-	m, err := dissect.NewMatchReader(in)
-	if err != nil {
-		return err
-	}
-	if err := m.Read(); !dissect.Ok(err) {
-		return err
+func help(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "r6-dissect: Match Replay API/CLI for Rainbow Six: Siege's Dissect (.rec) format.")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Usage:\n  r6-dissect <command> [flags] [inputs...]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Commands:")
+		for _, c := range commands {
+			fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
+		}
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Inputs may be .rec files, match folders, glob patterns or - for stdin.")
+		fmt.Fprintf(os.Stderr, "The %s command runs when no command is specified.\n", defaultCommand)
+		fmt.Fprintln(os.Stderr, "Run 'r6-dissect help <command>' for more information about a command.")
+		return exitOK
 	}
-	if format == Excel {
-		return m.WriteExcel(out)
+	c := findCommand(args[0])
+	if c == nil {
+		log.Error().Msgf("unknown command %q", args[0])
+		return exitUsage
 	}
-	return m.WriteJSON(out)
+	return run([]string{c.name, "--help"})
 }
 
-func writeRound(in io.Reader, out io.Writer) error {
-	r, err := dissect.NewReader(in)
-	if err != nil {
-		return err
-	}
-	type output struct {
-		dissect.Header
-		MatchFeedback []dissect.MatchUpdate      `json:"matchFeedback"`
-		PlayerStats   []dissect.PlayerRoundStats `json:"stats"`
+func printUsage(c *command, fs *pflag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Usage:\n  r6-dissect %s [flags] %s\n\n", c.name, c.args)
+	fmt.Fprintf(os.Stderr, "%s\n\nFlags:\n", strings.ToUpper(c.summary[:1])+c.summary[1:])
+	fmt.Fprint(os.Stderr, fs.FlagUsages())
+}
+
+func outputFlags(fs *pflag.FlagSet) {
+	fs.StringP("output", "o", "", "specifies the output path")
+}
+
+func formatFlags(fs *pflag.FlagSet) {
+	fs.StringP("format", "f", "", fmt.Sprintf("specifies the output format (%s)", strings.Join(outputFormats, ", ")))
+	fs.String("partition", "match", "partitions parquet files by match or day")
+	fs.String("template", "", "specifies a text/template file overriding the html or md report")
+	fs.String("excel-layout", "", "specifies a yaml or json file overriding the excel workbook layout")
+	outputFlags(fs)
+}
+
+// outputFormat returns the format flag, or infers it from the output extension.
+func outputFormat() (OutputFormat, error) {
+	format := strings.ToLower(viper.GetString("format"))
+	if len(format) == 0 {
+		output := viper.GetString("output")
+		switch filepath.Ext(output) {
+		case ".xlsx":
+			return Excel, nil
+		case ".db", ".sqlite":
+			return SQLite, nil
+		case ".ndjson", ".jsonl":
+			return NDJSON, nil
+		case ".html":
+			return HTML, nil
+		case ".md":
+			return Markdown, nil
+		}
+		return JSON, nil
 	}
-	if err := r.Read(); !dissect.Ok(err) {
-		return err
+	for _, f := range outputFormats {
+		if f == format {
+			return f, nil
+		}
 	}
-	encoder := json.NewEncoder(out)
-	return encoder.Encode(output{
-		r.Header,
-		r.MatchFeedback,
-		r.PlayerStats(),
-	})
+	return "", newUsageError("specify a valid output format (%s)", strings.Join(outputFormats, ", "))
 }
 
-func writeRoundDump(in io.Reader, out *os.File) error {
-	r, err := dissect.NewReader(in)
-	if err != nil {
-		return err
+// formatExtension returns the file extension of a format,
+// or an empty string for formats written to a directory.
+func formatExtension(format OutputFormat) string {
+	switch format {
+	case Excel:
+		return ".xlsx"
+	case CSV, Parquet:
+		return ""
+	case SQLite:
+		return ".db"
+	case NDJSON:
+		return ".ndjson"
+	case HTML:
+		return ".html"
+	case Markdown:
+		return ".md"
 	}
-	_, err = r.Write(out)
-	return err
+	return ".json"
 }
 
 func piped(f *os.File) bool {
@@ -202,3 +364,7 @@ func viperFileOrDefault(key string, def *os.File, flag int) (*os.File, error) {
 	}
 	return def, nil
 }
+
+func openOutput() (*os.File, error) {
+	return viperFileOrDefault("output", os.Stdout, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+}
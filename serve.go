@@ -0,0 +1,493 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/redraskal/r6-dissect/dissect"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/pflag"
+)
+
+type exportKind string
+
+const (
+	roundExport     exportKind = "round"
+	roundInfoExport exportKind = "round-info"
+	matchExport     exportKind = "match"
+	matchInfoExport exportKind = "match-info"
+)
+
+var errInputRequired = errors.New("upload a replay with multipart/form-data or specify a path")
+var errPathsDisabled = errors.New("path requests are disabled, start the server with --root")
+var errPathOutsideRoot = errors.New("path is outside of the server root")
+
+type server struct {
+	sem       chan struct{}
+	maxUpload int64 // maximum request size, and total size of the replays extracted from an upload
+	cacheDir  string
+	root      string // local folder path requests are restricted to, disabled when empty
+	stats     statsOptions
+	read      func(ctx context.Context, kind exportKind, path string, out io.Writer) error
+}
+
+// serverInput is a replay file or match folder ready to be read.
+// Uploads are copied to a temporary location which cleanup removes.
+type serverInput struct {
+	path    string
+	hash    string
+	cleanup func()
+}
+
+func serveFlags(fs *pflag.FlagSet) {
+	fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	fs.Int("concurrency", runtime.NumCPU(), "maximum replays read at once")
+	fs.Int64("max-upload", 512<<20, "maximum request size in bytes")
+	fs.String("cache", "", "directory to cache responses in, keyed by file hash")
+	fs.String("root", "", "local folder replay paths may be read from")
+}
+
+func serve(addr string, concurrency int, maxUpload int64, cacheDir, root string, stats statsOptions) error {
+	s, err := newServer(concurrency, maxUpload, cacheDir, root, stats)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/round", s.handle(roundExport))
+	mux.HandleFunc("/round/info", s.handle(roundInfoExport))
+	mux.HandleFunc("/match", s.handle(matchExport))
+	mux.HandleFunc("/match/info", s.handle(matchInfoExport))
+	log.Info().Str("addr", addr).Int("concurrency", concurrency).Msg("serving")
+	return http.ListenAndServe(addr, mux)
+}
+
+func newServer(concurrency int, maxUpload int64, cacheDir, root string, stats statsOptions) (*server, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if len(cacheDir) > 0 {
+		if err := os.MkdirAll(cacheDir, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	if len(root) > 0 {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		root = abs
+	}
+	s := &server{
+		sem:       make(chan struct{}, concurrency),
+		maxUpload: maxUpload,
+		cacheDir:  cacheDir,
+		root:      root,
+		stats:     stats,
+	}
+	s.read = s.exportInput
+	return s, nil
+}
+
+func (s *server) handle(kind exportKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var in serverInput
+		var err error
+		switch req.Method {
+		case http.MethodGet:
+			in, err = s.localInput(req.URL.Query().Get("path"), kind)
+		case http.MethodPost:
+			req.Body = http.MaxBytesReader(w, req.Body, s.maxUpload)
+			in, err = s.uploadInput(req, kind)
+		default:
+			writeServerError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		if err != nil {
+			writeServerError(w, inputErrorStatus(err), err)
+			return
+		}
+		if b, ok := s.cached(kind, in.hash); ok {
+			in.cleanup()
+			writeServerJSON(w, b)
+			return
+		}
+		ctx := req.Context()
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			in.cleanup()
+			return
+		}
+		b, err := s.export(ctx, kind, in)
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		if err != nil {
+			writeServerError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		s.cache(kind, in.hash, b)
+		writeServerJSON(w, b)
+	}
+}
+
+// export reads the input in a separate goroutine so that a cancelled
+// request returns without waiting for the read to finish. The goroutine
+// owns the semaphore slot and the input until the read returns, so
+// cancelled requests cannot exceed the concurrency limit or remove
+// an upload that is still being read.
+func (s *server) export(ctx context.Context, kind exportKind, in serverInput) ([]byte, error) {
+	type result struct {
+		b   []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			// net/http only recovers panics on the handler goroutine
+			if p := recover(); p != nil {
+				log.Error().Interface("panic", p).Str("kind", string(kind)).Msg("replay read panicked")
+				res = result{err: fmt.Errorf("could not read replay: %v", p)}
+			}
+			in.cleanup()
+			<-s.sem
+			done <- res
+		}()
+		var buf bytes.Buffer
+		err := s.read(ctx, kind, in.path, &buf)
+		res = result{buf.Bytes(), err}
+	}()
+	select {
+	case res := <-done:
+		return res.b, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *server) exportInput(ctx context.Context, kind exportKind, path string, out io.Writer) error {
+	switch kind {
+	case roundExport, roundInfoExport:
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r, err := dissect.NewReader(f)
+		if err != nil {
+			return err
+		}
+		s.stats.round(r)
+		if kind == roundInfoExport {
+			if err := r.ReadPartial(); !dissect.Ok(err) {
+				return err
+			}
+			return json.NewEncoder(out).Encode(r.Header)
+		}
+		if err := r.Read(); !dissect.Ok(err) {
+			return err
+		}
+		return r.WriteJSON(out)
+	case matchInfoExport:
+		dir, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer dir.Close()
+		paths, err := dissect.ListReplayFiles(dir)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return dissect.ErrInvalidFolder
+		}
+		return s.exportInput(ctx, roundInfoExport, paths[0], out)
+	default:
+		dir, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer dir.Close()
+		m, err := dissect.NewMatchReader(dir)
+		if err != nil {
+			return err
+		}
+		s.stats.match(m)
+		for i := 0; i < m.NumRounds(); i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := m.RoundAt(i); !dissect.Ok(err) {
+				return err
+			}
+		}
+		return m.WriteJSON(out)
+	}
+}
+
+func (s *server) localInput(path string, kind exportKind) (in serverInput, err error) {
+	if len(path) == 0 {
+		return in, errInputRequired
+	}
+	if len(s.root) == 0 {
+		return in, errPathsDisabled
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(s.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return in, errPathOutsideRoot
+	}
+	h := sha256.New()
+	if isMatchKind(kind) {
+		dir, err := os.Open(abs)
+		if err != nil {
+			return in, err
+		}
+		paths, err := dissect.ListReplayFiles(dir)
+		dir.Close()
+		if err != nil {
+			return in, err
+		}
+		for _, p := range paths {
+			if err = hashFile(h, p); err != nil {
+				return in, err
+			}
+		}
+	} else if err = hashFile(h, abs); err != nil {
+		return
+	}
+	return serverInput{
+		path:    abs,
+		hash:    hex.EncodeToString(h.Sum(nil)),
+		cleanup: func() {},
+	}, nil
+}
+
+// uploadInput streams multipart files to a temporary folder. Rounds accept a single
+// .rec file, matches accept .rec files for each round or a .zip of a match folder.
+func (s *server) uploadInput(req *http.Request, kind exportKind) (in serverInput, err error) {
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return in, errInputRequired
+	}
+	tmp, err := os.MkdirTemp("", "r6-dissect-")
+	if err != nil {
+		return
+	}
+	in.cleanup = func() {
+		os.RemoveAll(tmp)
+	}
+	defer func() {
+		if err != nil {
+			in.cleanup()
+		}
+	}()
+	h := sha256.New()
+	files := 0
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return in, err
+		}
+		name := filepath.Base(part.FileName())
+		if part.FileName() == "" || name == "." {
+			continue
+		}
+		if !isMatchKind(kind) && files > 0 {
+			return in, errors.New("only one round file may be uploaded")
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".rec" && !(ext == ".zip" && isMatchKind(kind)) {
+			return in, fmt.Errorf("unsupported upload %q", name)
+		}
+		path := filepath.Join(tmp, name)
+		if err = saveUpload(io.TeeReader(part, h), path); err != nil {
+			return in, err
+		}
+		if ext == ".zip" {
+			if err = extractReplays(path, tmp, s.maxUpload); err != nil {
+				return in, err
+			}
+			if err = os.Remove(path); err != nil {
+				return in, err
+			}
+		}
+		files++
+	}
+	if files == 0 {
+		return in, errInputRequired
+	}
+	in.hash = hex.EncodeToString(h.Sum(nil))
+	in.path = tmp
+	if !isMatchKind(kind) {
+		entries, err := os.ReadDir(tmp)
+		if err != nil {
+			return in, err
+		}
+		in.path = filepath.Join(tmp, entries[0].Name())
+	}
+	return in, nil
+}
+
+func saveUpload(r io.Reader, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// extractReplays copies every .rec file in the zip archive to dir,
+// ignoring the folder structure of the archive. Archives extracting
+// to more than limit bytes are rejected with a *http.MaxBytesError.
+func extractReplays(archive, dir string, limit int64) error {
+	z, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	remaining := limit
+	for _, f := range z.File {
+		name := filepath.Base(f.Name)
+		if f.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(name), ".rec") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		// one byte over the remaining size detects archives over the limit
+		counter := &countingReader{r: io.LimitReader(rc, remaining+1)}
+		err = saveUpload(counter, filepath.Join(dir, name))
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		remaining -= counter.n
+		if remaining < 0 {
+			return &http.MaxBytesError{Limit: limit}
+		}
+	}
+	return nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
+
+func isMatchKind(kind exportKind) bool {
+	return kind == matchExport || kind == matchInfoExport
+}
+
+// cachePath keys cached responses by the input hash and everything else
+// the output depends on, so upgrades and different settings are not
+// served stale JSON.
+func (s *server) cachePath(kind exportKind, hash string) string {
+	version := strings.NewReplacer("/", "_", "\\", "_").Replace(Version)
+	name := fmt.Sprintf("%s-%s-schema%d-%s-%s.json", kind, hash, dissect.SchemaVersion, version, s.stats.key())
+	return filepath.Join(s.cacheDir, name)
+}
+
+// key identifies the options in cache file names.
+func (opts statsOptions) key() string {
+	window := opts.tradeWindow
+	if window == 0 {
+		window = dissect.DefaultTradeWindow
+	}
+	weights := dissect.DefaultRatingWeights
+	if opts.ratingWeights != nil {
+		weights = *opts.ratingWeights
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v", weights)))
+	return fmt.Sprintf("trade%g-rating%x", window, sum[:4])
+}
+
+func (s *server) cached(kind exportKind, hash string) ([]byte, bool) {
+	if len(s.cacheDir) == 0 {
+		return nil, false
+	}
+	b, err := os.ReadFile(s.cachePath(kind, hash))
+	if err != nil {
+		return nil, false
+	}
+	log.Debug().Str("kind", string(kind)).Str("hash", hash).Msg("cache hit")
+	return b, true
+}
+
+func (s *server) cache(kind exportKind, hash string, b []byte) {
+	if len(s.cacheDir) == 0 {
+		return
+	}
+	if err := os.WriteFile(s.cachePath(kind, hash), b, 0644); err != nil {
+		log.Warn().Err(err).Msg("could not write to cache")
+	}
+}
+
+func inputErrorStatus(err error) int {
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytes):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errPathsDisabled), errors.Is(err, errPathOutsideRoot):
+		return http.StatusForbidden
+	case errors.Is(err, os.ErrNotExist):
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
+
+func writeServerJSON(w http.ResponseWriter, b []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func writeServerError(w http.ResponseWriter, status int, err error) {
+	type export struct {
+		Error string `json:"error"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(export{err.Error()})
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// uploadRequest returns a multipart request uploading a file named name.
+func uploadRequest(t *testing.T, target, name string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = part.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err = mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func testServer(t *testing.T, root string) *server {
+	t.Helper()
+	// uploads are extracted to the temporary directory
+	t.Setenv("TMPDIR", t.TempDir())
+	s, err := newServer(1, 1<<20, t.TempDir(), root, statsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// uploads returns the temporary upload folders that were not cleaned up.
+func uploads(t *testing.T) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(os.TempDir(), "r6-dissect-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestServerUpload(t *testing.T) {
+	s := testServer(t, "")
+	var uploaded []byte
+	s.read = func(ctx context.Context, kind exportKind, path string, out io.Writer) error {
+		var err error
+		uploaded, err = os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(out, `{"round":1}`)
+		return err
+	}
+	w := httptest.NewRecorder()
+	s.handle(roundExport)(w, uploadRequest(t, "/round", "R01.rec", []byte("replay")))
+	if w.Code != http.StatusOK || w.Body.String() != `{"round":1}` {
+		t.Fatalf("upload: got %d %q", w.Code, w.Body.String())
+	}
+	if string(uploaded) != "replay" {
+		t.Errorf("upload: read %q, want %q", uploaded, "replay")
+	}
+	if left := uploads(t); len(left) > 0 {
+		t.Errorf("upload: temporary files were not removed: %v", left)
+	}
+	w = httptest.NewRecorder()
+	s.handle(roundExport)(w, uploadRequest(t, "/round", "R01.txt", []byte("replay")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unsupported upload: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServerUpload_ZipOverLimit(t *testing.T) {
+	s := testServer(t, "")
+	s.maxUpload = 1 << 10
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, err := zw.Create("Match/R01.rec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// compresses to far less than the limit
+	if _, err = f.Write(bytes.Repeat([]byte{0}, 1<<20)); err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	s.handle(matchExport)(w, uploadRequest(t, "/match", "match.zip", archive.Bytes()))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got %d %q, want %d", w.Code, w.Body.String(), http.StatusRequestEntityTooLarge)
+	}
+	if left := uploads(t); len(left) > 0 {
+		t.Errorf("temporary files were not removed: %v", left)
+	}
+}
+
+func TestServerReadPanic(t *testing.T) {
+	s := testServer(t, "")
+	s.read = func(ctx context.Context, kind exportKind, path string, out io.Writer) error {
+		panic("index out of range [-1]")
+	}
+	w := httptest.NewRecorder()
+	s.handle(roundExport)(w, uploadRequest(t, "/round", "R01.rec", []byte("replay")))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d %q, want %d", w.Code, w.Body.String(), http.StatusUnprocessableEntity)
+	}
+	if len(s.sem) != 0 {
+		t.Error("the slot was not released after the read panicked")
+	}
+	if left := uploads(t); len(left) > 0 {
+		t.Errorf("temporary files were not removed: %v", left)
+	}
+}
+
+func TestServerPathOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	s := testServer(t, root)
+	outside := filepath.Join(filepath.Dir(root), "R01.rec")
+	w := httptest.NewRecorder()
+	s.handle(roundExport)(w, httptest.NewRequest(http.MethodGet, "/round?path="+url.QueryEscape(outside), nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestServerCacheHit(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "R01.rec")
+	if err := os.WriteFile(path, []byte("replay"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := testServer(t, root)
+	reads := 0
+	s.read = func(ctx context.Context, kind exportKind, path string, out io.Writer) error {
+		reads++
+		_, err := io.WriteString(out, `{"round":1}`)
+		return err
+	}
+	target := "/round?path=" + url.QueryEscape(path)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		s.handle(roundExport)(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK || w.Body.String() != `{"round":1}` {
+			t.Fatalf("request %d: got %d %q", i+1, w.Code, w.Body.String())
+		}
+	}
+	if reads != 1 {
+		t.Errorf("expected the second request to be served from the cache, read %d times", reads)
+	}
+	entries, err := os.ReadDir(s.cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0].Name(), "schema") {
+		t.Errorf("expected a cache file keyed by the schema version, got %v", entries)
+	}
+}
+
+// TestServerCancelReleasesSlot checks that a cancelled request keeps its
+// slot and upload until the read returns, then releases both.
+func TestServerCancelReleasesSlot(t *testing.T) {
+	s := testServer(t, "")
+	started := make(chan string)
+	unblock := make(chan struct{})
+	s.read = func(ctx context.Context, kind exportKind, path string, out io.Writer) error {
+		started <- path
+		<-unblock
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	req := uploadRequest(t, "/round", "R01.rec", []byte("replay")).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.handle(roundExport)(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	path := <-started
+	cancel()
+	<-done
+	if len(s.sem) != 1 {
+		t.Fatal("the slot was released while the upload was still being read")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("the upload was removed while it was being read: %v", err)
+	}
+	close(unblock)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.sem) != 0 || len(uploads(t)) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the slot or upload was not released after the read returned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
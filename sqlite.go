@@ -0,0 +1,36 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/redraskal/r6-dissect/dissect"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteFile is a dissect.SQLiteWriter that owns its database.
+type sqliteFile struct {
+	*dissect.SQLiteWriter
+	db *sql.DB
+}
+
+func openSQLite(path string) (*sqliteFile, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// a single connection serializes writes and avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec("PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000"); err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	w, err := dissect.NewSQLiteWriter(db)
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	return &sqliteFile{w, db}, nil
+}
+
+func (f *sqliteFile) Close() error {
+	return f.db.Close()
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/redraskal/r6-dissect/dissect"
+	"github.com/rs/zerolog/log"
+)
+
+const watchStateFile = ".r6-dissect-watch.json"
+
+const watchDatabase = "r6-dissect.db"
+
+const defaultSettle = 5 * time.Second
+
+// watchState records the rounds and matches a watcher has already exported,
+// and the rounds that could not be read.
+// It is stored in the output directory so restarts do not export twice.
+type watchState struct {
+	Rounds  map[string]bool `json:"rounds"`
+	Failed  map[string]bool `json:"failed"`
+	Matches map[string]bool `json:"matches"`
+}
+
+type pendingFile struct {
+	size    int64
+	modTime time.Time
+}
+
+type watcher struct {
+	root    string
+	out     string
+	format  OutputFormat
+	settle  time.Duration
+	stats   statsOptions
+	state   watchState
+	pending map[string]pendingFile
+	fs      *fsnotify.Watcher
+}
+
+func watch(root, out string, format OutputFormat, settle time.Duration, stats statsOptions) error {
+	if len(out) == 0 {
+		return errors.New("watch requires an output directory (-o)")
+	}
+	if err := os.MkdirAll(out, os.ModePerm); err != nil {
+		return err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+	w := &watcher{
+		root:    root,
+		out:     out,
+		format:  format,
+		settle:  settle,
+		stats:   stats,
+		pending: make(map[string]pendingFile),
+		fs:      fsw,
+	}
+	if err = w.loadState(); err != nil {
+		return err
+	}
+	if err = w.fs.Add(root); err != nil {
+		return err
+	}
+	log.Info().Str("replays", root).Str("output", out).Msg("watching for new matches")
+	if err = w.scan(); err != nil {
+		return err
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	// fsnotify events only hint that something changed, the periodic
+	// scan decides when a round file has settled.
+	ticker := time.NewTicker(settle)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			log.Debug().Str("event", event.String()).Send()
+			if event.Has(fsnotify.Create) {
+				if stat, err := os.Stat(event.Name); err == nil && stat.IsDir() {
+					if err = w.fs.Add(event.Name); err != nil {
+						log.Warn().Err(err).Str("path", event.Name).Msg("could not watch match folder")
+					}
+				}
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warn().Err(err).Msg("watch error")
+		case <-ticker.C:
+			if err = w.scan(); err != nil {
+				return err
+			}
+		case <-interrupt:
+			log.Info().Msg("stopping watch")
+			return w.saveState()
+		}
+	}
+}
+
+func (w *watcher) loadState() error {
+	w.state = watchState{
+		Rounds:  make(map[string]bool),
+		Failed:  make(map[string]bool),
+		Matches: make(map[string]bool),
+	}
+	f, err := os.Open(filepath.Join(w.out, watchStateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = json.NewDecoder(f).Decode(&w.state); err != nil {
+		return err
+	}
+	// state files written before failed rounds were recorded
+	if w.state.Failed == nil {
+		w.state.Failed = make(map[string]bool)
+	}
+	return nil
+}
+
+func (w *watcher) saveState() error {
+	path := filepath.Join(w.out, watchStateFile)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(f).Encode(w.state); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// scan exports every settled round that has not been processed yet.
+// Matches are exported once a team has won, or once a newer match folder exists.
+func (w *watcher) scan() error {
+	entries, err := os.ReadDir(w.root)
+	if err != nil {
+		return err
+	}
+	folders := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "Match-") {
+			folders = append(folders, filepath.Join(w.root, entry.Name()))
+		}
+	}
+	changed := false
+	for i, folder := range folders {
+		if w.state.Matches[folder] {
+			continue
+		}
+		// folders are added while watching if they were created before the watch started
+		if err := w.fs.Add(folder); err != nil {
+			log.Warn().Err(err).Str("path", folder).Msg("could not watch match folder")
+		}
+		processed, over, err := w.scanMatch(folder)
+		if err != nil {
+			log.Error().Err(err).Str("path", folder).Msg("failed to scan match folder")
+			continue
+		}
+		if processed > 0 {
+			changed = true
+		}
+		newer := i < len(folders)-1
+		if !over && !newer {
+			continue
+		}
+		if !w.roundsProcessed(folder) {
+			continue
+		}
+		if err := w.exportMatch(folder); err != nil {
+			log.Error().Err(err).Str("path", folder).Msg("failed to export match")
+			continue
+		}
+		w.state.Matches[folder] = true
+		changed = true
+	}
+	if changed {
+		return w.saveState()
+	}
+	return nil
+}
+
+// scanMatch exports the settled rounds of a match folder and returns how many
+// were processed. over is true when the latest round read shows a team reaching
+// the winning score. Rounds that cannot be exported are logged and marked failed.
+func (w *watcher) scanMatch(folder string) (processed int, over bool, err error) {
+	dir, err := os.Open(folder)
+	if err != nil {
+		return
+	}
+	paths, err := dissect.ListReplayFiles(dir)
+	dir.Close()
+	if errors.Is(err, dissect.ErrInvalidFolder) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		if w.state.Rounds[path] || w.state.Failed[path] || !w.settled(path) {
+			continue
+		}
+		h, err := w.exportRound(folder, path)
+		if err != nil {
+			log.Error().Err(err).Str("round", filepath.Base(path)).Msg("failed to export round")
+			w.state.Failed[path] = true
+			processed++
+			continue
+		}
+		w.state.Rounds[path] = true
+		processed++
+		over = matchOver(h)
+		log.Info().Str("round", filepath.Base(path)).Msg("exported round")
+	}
+	return
+}
+
+// settled returns true once the size and modification time of a round file
+// have not changed for the settle duration.
+func (w *watcher) settled(path string) bool {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	last, ok := w.pending[path]
+	w.pending[path] = pendingFile{stat.Size(), stat.ModTime()}
+	if !ok || last.size != stat.Size() || !last.modTime.Equal(stat.ModTime()) {
+		return false
+	}
+	if time.Since(stat.ModTime()) < w.settle {
+		return false
+	}
+	delete(w.pending, path)
+	return true
+}
+
+func (w *watcher) roundsProcessed(folder string) bool {
+	dir, err := os.Open(folder)
+	if err != nil {
+		return false
+	}
+	defer dir.Close()
+	paths, err := dissect.ListReplayFiles(dir)
+	if err != nil {
+		return false
+	}
+	for _, path := range paths {
+		if !w.state.Rounds[path] && !w.state.Failed[path] {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *watcher) exportRound(folder, path string) (dissect.Header, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return dissect.Header{}, err
+	}
+	defer in.Close()
+	dir := filepath.Join(w.out, filepath.Base(folder))
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return dissect.Header{}, err
+	}
+	name := filepath.Join(dir, strings.TrimSuffix(filepath.Base(path), ".rec")+formatExtension(w.format))
+	r, err := dissect.NewReader(in)
+	if err != nil {
+		return dissect.Header{}, err
+	}
+	w.stats.round(r)
+	if w.format == NDJSON {
+		out, err := os.Create(name)
+		if err != nil {
+			return r.Header, err
+		}
+		defer out.Close()
+		err = dissect.StreamRound(r, out)
+		return r.Header, err
+	}
+	if err := r.Read(); !dissect.Ok(err) {
+		return r.Header, err
+	}
+	switch w.format {
+	case CSV:
+		return r.Header, writeTables(w.format, name, nil, r)
+	case SQLite:
+		return r.Header, writeTables(w.format, w.database(), nil, r)
+	case Parquet:
+		// parquet files hold whole matches and are written by exportMatch
+		return r.Header, nil
+	}
+	out, err := os.Create(name)
+	if err != nil {
+		return r.Header, err
+	}
+	defer out.Close()
+	if w.format == Excel {
+		layout, err := excelLayout()
+		if err != nil {
+			return r.Header, err
+		}
+		return r.Header, r.WriteExcelLayout(out, layout)
+	}
+	return r.Header, r.WriteJSON(out)
+}
+
+func (w *watcher) exportMatch(folder string) error {
+	in, err := os.Open(folder)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	name := filepath.Join(w.out, filepath.Base(folder)+formatExtension(w.format))
+	switch w.format {
+	case SQLite:
+		name = w.database()
+	case Parquet:
+		name = w.out
+	}
+	if w.format == CSV || w.format == SQLite || w.format == Parquet {
+		var m *dissect.MatchReader
+		if m, err = dissect.NewMatchReader(in); err != nil {
+			return err
+		}
+		w.stats.match(m)
+		if err = m.Read(); !dissect.Ok(err) {
+			return err
+		}
+		err = writeTables(w.format, name, m, nil)
+	} else {
+		var out *os.File
+		if out, err = os.Create(name); err != nil {
+			return err
+		}
+		err = writeMatch(in, w.format, out, w.stats)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Info().Str("match", filepath.Base(folder)).Msg("exported match")
+	return nil
+}
+
+// database returns the path of the SQLite database that
+// every round and match is written to.
+func (w *watcher) database() string {
+	return filepath.Join(w.out, watchDatabase)
+}
+
+// matchOver returns true if a team reached the winning score
+// in regulation or overtime after the round described by h.
+func matchOver(h dissect.Header) bool {
+	half := h.RoundsPerMatch / 2
+	target := half + 1
+	a, b := h.Teams[0].Score, h.Teams[1].Score
+	if a >= half && b >= half {
+		target = half + h.RoundsPerMatchOvertime/2 + 1
+	}
+	return a >= target || b >= target
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// invalidMatch returns a match folder with a round that cannot be read.
+func invalidMatch(t *testing.T) string {
+	t.Helper()
+	b, err := os.ReadFile("dissect/test/data/replays/invalid/not_zstd.rec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	folder := filepath.Join(t.TempDir(), "Match-invalid")
+	if err = os.Mkdir(folder, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(folder, "R01.rec"), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return folder
+}
+
+func TestWatcherExportMatch_Error(t *testing.T) {
+	for _, format := range []OutputFormat{JSON, CSV} {
+		t.Run(format, func(t *testing.T) {
+			w := &watcher{out: t.TempDir(), format: format}
+			if err := w.exportMatch(invalidMatch(t)); err == nil {
+				t.Fatal("exportMatch(): expected err, got nil")
+			}
+		})
+	}
+}
+
+func TestWatcherScan_UnreadableRound(t *testing.T) {
+	folder := invalidMatch(t)
+	root := filepath.Dir(folder)
+	// a newer match folder marks the invalid match as finished
+	if err := os.Mkdir(filepath.Join(root, "Match-newer"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsw.Close()
+	w := &watcher{
+		root:    root,
+		out:     t.TempDir(),
+		format:  JSON,
+		pending: make(map[string]pendingFile),
+		fs:      fsw,
+	}
+	if err = w.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	// the first scan only records the round size, the second exports it
+	for i := 0; i < 2; i++ {
+		if err = w.scan(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	round := filepath.Join(folder, "R01.rec")
+	if !w.state.Failed[round] || w.state.Rounds[round] {
+		t.Errorf("expected %s to be marked failed, got %+v", round, w.state)
+	}
+	if w.state.Matches[folder] {
+		t.Errorf("expected the failed match export to be retried, got %+v", w.state)
+	}
+}